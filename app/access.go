@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Reasons a request is rejected by the access policy.
+const (
+	RejectUnauthorized = "unauthorized"
+	RejectIP           = "ip"
+	RejectOrigin       = "origin"
+)
+
+// corsAllowHeaders lists the request headers clients may send cross-origin.
+const corsAllowHeaders = "Content-Type, Content-Encoding, Authorization, X-VersaDumps-Service, X-Request-Id, traceparent"
+
+// AccessPolicy guards every endpoint of the ingest server with an optional
+// bearer token, IP/CIDR allowlist and CORS origin allowlist.
+type AccessPolicy struct {
+	token       string
+	restrictIPs bool
+	networks    []*net.IPNet
+	origins     map[string]bool // empty allows any origin
+	onReject    func(reason string, r *http.Request)
+}
+
+// NewAccessPolicy builds the policy of profile. An invalid allowlist entry
+// is reported as an error together with a policy that only admits loopback
+// clients, so a typo never opens the server up.
+func NewAccessPolicy(profile Profile) (*AccessPolicy, error) {
+	p := &AccessPolicy{
+		token:       profile.AuthToken,
+		restrictIPs: len(profile.AllowedIPs) > 0,
+		origins:     make(map[string]bool),
+	}
+	for _, origin := range profile.CORSOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			p.origins = make(map[string]bool)
+			break
+		}
+		if origin != "" {
+			p.origins[origin] = true
+		}
+	}
+
+	networks, err := parseAllowedNetworks(profile.AllowedIPs)
+	if err != nil {
+		return p, err
+	}
+	// The app's own health checks come from the bound address
+	if ip := net.ParseIP(profile.Server); ip != nil && !ip.IsUnspecified() {
+		own, _ := parseAllowedNetworks([]string{profile.Server})
+		networks = append(networks, own...)
+	}
+	p.networks = networks
+	return p, nil
+}
+
+// parseAllowedNetworks parses IPs and CIDRs; a bare IP matches only itself.
+func parseAllowedNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR '%s' in allowed_ips", entry)
+			}
+			networks = append(networks, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP '%s' in allowed_ips", entry)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
+
+// allowsIP reports whether the client at remoteAddr may connect. Loopback
+// and Unix socket clients are always allowed so local clients and the app
+// itself keep working.
+func (p *AccessPolicy) allowsIP(remoteAddr string) bool {
+	if !p.restrictIPs || remoteAddr == "" || remoteAddr == "@" {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsOrigin reports whether a browser request from origin may proceed.
+// Requests without an Origin header (curl, PHP) are not browser requests.
+func (p *AccessPolicy) allowsOrigin(origin string) bool {
+	return origin == "" || len(p.origins) == 0 || p.origins[origin]
+}
+
+// hasToken reports whether r carries the bearer token.
+func (p *AccessPolicy) hasToken(r *http.Request) bool {
+	if p.token == "" {
+		return true
+	}
+	auth := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(p.token)) == 1
+}
+
+// Wrap applies the policy in front of next. Share links carry their own
+// token in the URL, so /share endpoints skip the bearer check.
+func (p *AccessPolicy) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.allowsIP(r.RemoteAddr) {
+			p.reject(w, r, RejectIP, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		origin := r.Header.Get("Origin")
+		if !p.allowsOrigin(origin) {
+			p.reject(w, r, RejectOrigin, http.StatusForbidden, "Origin not allowed")
+			return
+		}
+		p.setCORSHeaders(w, origin)
+
+		// Preflight requests never carry credentials
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		isShare := r.URL.Path == "/share" || strings.HasPrefix(r.URL.Path, "/share/")
+		if !isShare && !p.hasToken(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="VersaDumps"`)
+			p.reject(w, r, RejectUnauthorized, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *AccessPolicy) setCORSHeaders(w http.ResponseWriter, origin string) {
+	h := w.Header()
+	if len(p.origins) == 0 {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else if origin != "" {
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+	}
+	h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+}
+
+func (p *AccessPolicy) reject(w http.ResponseWriter, r *http.Request, reason string, status int, text string) {
+	if p.onReject != nil {
+		p.onReject(reason, r)
+	}
+	http.Error(w, text, status)
+}
+
+// RejectionStats counts requests refused by the access policy.
+type RejectionStats struct {
+	Unauthorized uint64 `json:"unauthorized"`
+	IP           uint64 `json:"ip"`
+	Origin       uint64 `json:"origin"`
+	Total        uint64 `json:"total"`
+}
+
+// RejectionCounter counts rejections across server restarts.
+type RejectionCounter struct {
+	unauthorized atomic.Uint64
+	ip           atomic.Uint64
+	origin       atomic.Uint64
+}
+
+// Add counts one rejection for reason.
+func (c *RejectionCounter) Add(reason string) {
+	switch reason {
+	case RejectUnauthorized:
+		c.unauthorized.Add(1)
+	case RejectIP:
+		c.ip.Add(1)
+	case RejectOrigin:
+		c.origin.Add(1)
+	}
+}
+
+// Stats returns the current counts.
+func (c *RejectionCounter) Stats() RejectionStats {
+	s := RejectionStats{
+		Unauthorized: c.unauthorized.Load(),
+		IP:           c.ip.Load(),
+		Origin:       c.origin.Load(),
+	}
+	s.Total = s.Unauthorized + s.IP + s.Origin
+	return s
+}
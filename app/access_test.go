@@ -0,0 +1,137 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveWithPolicy runs r through a policy built from profile and returns the response
+func serveWithPolicy(t *testing.T, profile Profile, r *http.Request, counter *RejectionCounter) *httptest.ResponseRecorder {
+	t.Helper()
+	policy, err := NewAccessPolicy(profile)
+	if err != nil {
+		t.Fatalf("NewAccessPolicy() failed: %v", err)
+	}
+	policy.onReject = func(reason string, r *http.Request) { counter.Add(reason) }
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	rec := httptest.NewRecorder()
+	policy.Wrap(ok).ServeHTTP(rec, r)
+	return rec
+}
+
+// TestAccessPolicy tests token, IP allowlist and origin checks
+func TestAccessPolicy(t *testing.T) {
+	profile := Profile{
+		Server:      "0.0.0.0",
+		AuthToken:   "s3cret",
+		AllowedIPs:  []string{"172.17.0.0/16", "10.0.0.5"},
+		CORSOrigins: []string{"http://localhost:5173/"},
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		remote   string
+		auth     string
+		origin   string
+		expected int
+	}{
+		{"valid token from docker", "/data", "172.17.0.3:5000", "Bearer s3cret", "", http.StatusOK},
+		{"valid token from single ip", "/data", "10.0.0.5:5000", "bearer s3cret", "", http.StatusOK},
+		{"loopback always allowed", "/health", "127.0.0.1:5000", "Bearer s3cret", "", http.StatusOK},
+		{"missing token", "/data", "127.0.0.1:5000", "", "", http.StatusUnauthorized},
+		{"wrong token", "/health", "127.0.0.1:5000", "Bearer nope", "", http.StatusUnauthorized},
+		{"ip not allowed", "/data", "192.168.1.20:5000", "Bearer s3cret", "", http.StatusForbidden},
+		{"allowed origin", "/query", "127.0.0.1:5000", "Bearer s3cret", "http://localhost:5173", http.StatusOK},
+		{"other origin", "/query", "127.0.0.1:5000", "Bearer s3cret", "http://evil.test", http.StatusForbidden},
+		{"share uses its own token", "/share/ws", "10.0.0.5:5000", "", "", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			r.RemoteAddr = tc.remote
+			if tc.auth != "" {
+				r.Header.Set("Authorization", tc.auth)
+			}
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+
+			rec := serveWithPolicy(t, profile, r, &RejectionCounter{})
+			if rec.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, rec.Code)
+			}
+			if tc.origin != "" && tc.expected == http.StatusOK && rec.Header().Get("Access-Control-Allow-Origin") != tc.origin {
+				t.Errorf("Expected the allowed origin to be echoed, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+// TestAccessPolicy_Defaults tests that an empty policy keeps the server open with CORS *
+func TestAccessPolicy_Defaults(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.RemoteAddr = "203.0.113.9:4000"
+	r.Header.Set("Origin", "http://anywhere.test")
+
+	rec := serveWithPolicy(t, Profile{}, r, &RejectionCounter{})
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected CORS *, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+// TestAccessPolicy_Preflight tests that CORS preflights pass without a token
+func TestAccessPolicy_Preflight(t *testing.T) {
+	r := httptest.NewRequest(http.MethodOptions, "/data", nil)
+	r.RemoteAddr = "127.0.0.1:4000"
+	r.Header.Set("Origin", "http://localhost:5173")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+
+	rec := serveWithPolicy(t, Profile{AuthToken: "s3cret", CORSOrigins: []string{"http://localhost:5173"}}, r, &RejectionCounter{})
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Headers") != corsAllowHeaders {
+		t.Errorf("Unexpected allowed headers: %q", rec.Header().Get("Access-Control-Allow-Headers"))
+	}
+}
+
+// TestAccessPolicy_InvalidAllowlist tests that a bad entry only admits loopback
+func TestAccessPolicy_InvalidAllowlist(t *testing.T) {
+	policy, err := NewAccessPolicy(Profile{AllowedIPs: []string{"10.0.0.0/33"}})
+	if err == nil {
+		t.Fatal("NewAccessPolicy() should reject an invalid CIDR")
+	}
+	if !policy.allowsIP("127.0.0.1:1") || !policy.allowsIP("[::1]:1") {
+		t.Error("Loopback should still be allowed")
+	}
+	if !policy.allowsIP("@") {
+		t.Error("Unix socket clients should still be allowed")
+	}
+	if policy.allowsIP("10.0.0.1:1") {
+		t.Error("Other clients should be rejected")
+	}
+}
+
+// TestRejectionCounter tests counting rejections by reason
+func TestRejectionCounter(t *testing.T) {
+	counter := &RejectionCounter{}
+	profile := Profile{AuthToken: "s3cret", AllowedIPs: []string{"10.0.0.0/8"}}
+
+	for _, remote := range []string{"127.0.0.1:1", "127.0.0.1:2", "192.168.0.1:3"} {
+		r := httptest.NewRequest(http.MethodPost, "/data", nil)
+		r.RemoteAddr = remote
+		serveWithPolicy(t, profile, r, counter)
+	}
+
+	stats := counter.Stats()
+	if stats.Unauthorized != 2 || stats.IP != 1 || stats.Origin != 0 || stats.Total != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultAlertCooldown keeps a noisy rule from flooding the desktop.
+const defaultAlertCooldown = 10 * time.Second
+
+// notificationQueueSize bounds the notifications waiting to be shown.
+const notificationQueueSize = 32
+
+// Alert rule sources.
+const (
+	AlertSourceDump = "dump"
+	AlertSourceLog  = "log"
+)
+
+// AlertRule raises a desktop notification when a dump or log line matches.
+// All non-empty conditions must match.
+type AlertRule struct {
+	Name     string `yaml:"name" json:"name"`
+	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	Source   string `yaml:"source,omitempty" json:"source,omitempty"`     // "dump", "log" or empty for both
+	Label    string `yaml:"label,omitempty" json:"label,omitempty"`       // exact dump label
+	Color    string `yaml:"color,omitempty" json:"color,omitempty"`       // dump color/type, or log level
+	Contains string `yaml:"contains,omitempty" json:"contains,omitempty"` // case-insensitive text match
+	Where    string `yaml:"where,omitempty" json:"where,omitempty"`       // JSON path predicate, e.g. "context.balance < 0"
+	Cooldown int    `yaml:"cooldown,omitempty" json:"cooldown,omitempty"` // seconds between notifications
+}
+
+// AlertEvent is emitted to the frontend when a rule fires.
+type AlertEvent struct {
+	Rule    string    `json:"rule"`
+	Source  string    `json:"source"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	DumpID  int64     `json:"dump_id,omitempty"`
+	FiredAt time.Time `json:"fired_at"`
+}
+
+type compiledAlertRule struct {
+	rule      AlertRule
+	predicate *dumpPredicate
+	cooldown  time.Duration
+}
+
+// AlertEngine evaluates the active profile's rules against dumps and log lines.
+type AlertEngine struct {
+	mu        sync.Mutex
+	rules     []compiledAlertRule
+	lastFired map[string]time.Time
+	now       func() time.Time
+}
+
+// NewAlertEngine creates an engine with no rules.
+func NewAlertEngine() *AlertEngine {
+	return &AlertEngine{lastFired: make(map[string]time.Time), now: time.Now}
+}
+
+// ValidateAlertRules reports the first invalid rule, if any.
+func ValidateAlertRules(rules []AlertRule) error {
+	_, err := compileAlertRules(rules)
+	return err
+}
+
+func compileAlertRules(rules []AlertRule) ([]compiledAlertRule, error) {
+	compiled := make([]compiledAlertRule, 0, len(rules))
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("alert rule name is required")
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate alert rule '%s'", rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Source {
+		case "", AlertSourceDump, AlertSourceLog:
+		default:
+			return nil, fmt.Errorf("alert rule '%s': source must be 'dump' or 'log'", rule.Name)
+		}
+		if rule.Label == "" && rule.Color == "" && rule.Contains == "" && rule.Where == "" {
+			return nil, fmt.Errorf("alert rule '%s' has no conditions", rule.Name)
+		}
+		if rule.Cooldown < 0 {
+			return nil, fmt.Errorf("alert rule '%s': cooldown must not be negative", rule.Name)
+		}
+
+		c := compiledAlertRule{rule: rule, cooldown: defaultAlertCooldown}
+		if rule.Cooldown > 0 {
+			c.cooldown = time.Duration(rule.Cooldown) * time.Second
+		}
+		if rule.Where != "" {
+			p, err := parsePredicate(rule.Where)
+			if err != nil {
+				return nil, fmt.Errorf("alert rule '%s': %v", rule.Name, err)
+			}
+			c.predicate = p
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// SetRules replaces the rules. Invalid rule sets are rejected as a whole.
+func (e *AlertEngine) SetRules(rules []AlertRule) error {
+	compiled, err := compileAlertRules(rules)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = compiled
+	e.lastFired = make(map[string]time.Time)
+	return nil
+}
+
+// MatchDump returns an event for every rule that fires for msg.
+func (e *AlertEngine) MatchDump(msg *DumpMessage) []AlertEvent {
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+
+	title := "VersaDumps"
+	if msg.Label != "" {
+		title = "VersaDumps: " + msg.Label
+	}
+	var body string
+	if ctx, err := json.Marshal(msg.Context); err == nil {
+		body = truncateText(string(ctx), 200)
+	}
+
+	return e.match(AlertSourceDump, msg.Label, msg.Color, encoded, func(rule string) AlertEvent {
+		return AlertEvent{Rule: rule, Source: AlertSourceDump, Title: title, Body: body, DumpID: msg.ID}
+	})
+}
+
+// MatchLog returns an event for every rule that fires for entry.
+func (e *AlertEngine) MatchLog(entry LogEntry) []AlertEvent {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+	return e.match(AlertSourceLog, "", entry.Level, encoded, func(rule string) AlertEvent {
+		return AlertEvent{
+			Rule:   rule,
+			Source: AlertSourceLog,
+			Title:  "VersaDumps: " + entry.FileName,
+			Body:   truncateText(entry.Line, 200),
+		}
+	})
+}
+
+func (e *AlertEngine) match(source, label, color string, encoded []byte, build func(rule string) AlertEvent) []AlertEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.rules) == 0 {
+		return nil
+	}
+
+	var doc interface{}
+	text := strings.ToLower(string(encoded))
+	var events []AlertEvent
+	now := e.now()
+
+	for _, c := range e.rules {
+		rule := c.rule
+		if !rule.Enabled || (rule.Source != "" && rule.Source != source) {
+			continue
+		}
+		if rule.Label != "" && (source != AlertSourceDump || !strings.EqualFold(rule.Label, label)) {
+			continue
+		}
+		if rule.Color != "" && !strings.EqualFold(rule.Color, color) {
+			continue
+		}
+		if rule.Contains != "" && !strings.Contains(text, strings.ToLower(rule.Contains)) {
+			continue
+		}
+		if c.predicate != nil {
+			if doc == nil {
+				decoder := json.NewDecoder(bytes.NewReader(encoded))
+				decoder.UseNumber()
+				if err := decoder.Decode(&doc); err != nil {
+					continue
+				}
+			}
+			if !c.predicate.match(doc) {
+				continue
+			}
+		}
+
+		if last, ok := e.lastFired[rule.Name]; ok && now.Sub(last) < c.cooldown {
+			continue
+		}
+		e.lastFired[rule.Name] = now
+
+		ev := build(rule.Name)
+		ev.FiredAt = now
+		events = append(events, ev)
+	}
+	return events
+}
+
+// Notifier shows a native desktop notification.
+type Notifier interface {
+	Notify(title, body string) error
+}
+
+// noopNotifier is used where no notification backend is available.
+type noopNotifier struct{}
+
+func (noopNotifier) Notify(title, body string) error { return nil }
+
+type notification struct {
+	title string
+	body  string
+}
+
+// NotificationQueue shows notifications from a goroutine of its own, so a
+// slow or missing notification service never holds up the dumps and log
+// lines that raised them. Notifications that do not fit are dropped.
+type NotificationQueue struct {
+	notifier Notifier
+	onError  func(err error) // reports failed notifications; optional
+	queue    chan notification
+	start    sync.Once
+	dropped  atomic.Uint64
+}
+
+// NewNotificationQueue creates a queue showing notifications through
+// notifier and reporting failures to onError.
+func NewNotificationQueue(notifier Notifier, onError func(err error)) *NotificationQueue {
+	return &NotificationQueue{
+		notifier: notifier,
+		onError:  onError,
+		queue:    make(chan notification, notificationQueueSize),
+	}
+}
+
+// Send queues a notification without waiting. It reports false when the
+// queue is full and the notification was dropped.
+func (q *NotificationQueue) Send(title, body string) bool {
+	q.start.Do(func() { go q.run() })
+	select {
+	case q.queue <- notification{title: title, body: body}:
+		return true
+	default:
+		q.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns how many notifications did not fit in the queue.
+func (q *NotificationQueue) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+func (q *NotificationQueue) run() {
+	for n := range q.queue {
+		if err := q.notifier.Notify(n.title, n.body); err != nil && q.onError != nil {
+			q.onError(err)
+		}
+	}
+}
+
+func truncateText(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return truncateBytes(s, max) + "…"
+}
+
+// truncateBytes cuts s to at most max bytes without splitting a multi-byte
+// character.
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAlertEngine_MatchDump tests label, color, text and predicate conditions on dumps
+func TestAlertEngine_MatchDump(t *testing.T) {
+	rules := []AlertRule{
+		{Name: "payments", Enabled: true, Label: "payment"},
+		{Name: "errors", Enabled: true, Color: "red"},
+		{Name: "negative", Enabled: true, Where: "context.balance < 0"},
+		{Name: "timeout", Enabled: true, Contains: "TIMEOUT"},
+		{Name: "disabled", Enabled: false, Label: "payment"},
+		{Name: "logs only", Enabled: true, Source: AlertSourceLog, Label: "payment"},
+	}
+
+	testCases := []struct {
+		name     string
+		msg      *DumpMessage
+		expected []string
+	}{
+		{"label", &DumpMessage{Label: "Payment", Context: map[string]interface{}{"balance": 10}}, []string{"payments"}},
+		{"color", &DumpMessage{Color: "red", Context: "boom"}, []string{"errors"}},
+		{"predicate", &DumpMessage{Context: map[string]interface{}{"balance": -5}}, []string{"negative"}},
+		{"contains", &DumpMessage{Context: "gateway timeout"}, []string{"timeout"}},
+		{"no match", &DumpMessage{Context: "ok"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewAlertEngine()
+			if err := engine.SetRules(rules); err != nil {
+				t.Fatalf("SetRules() failed: %v", err)
+			}
+			events := engine.MatchDump(tc.msg)
+			if len(events) != len(tc.expected) {
+				t.Fatalf("Expected %d events, got %d: %+v", len(tc.expected), len(events), events)
+			}
+			for i, ev := range events {
+				if ev.Rule != tc.expected[i] || ev.Source != AlertSourceDump {
+					t.Errorf("Unexpected event: %+v", ev)
+				}
+			}
+		})
+	}
+}
+
+// TestAlertEngine_Cooldown tests that a rule does not fire again within its cooldown
+func TestAlertEngine_Cooldown(t *testing.T) {
+	engine := NewAlertEngine()
+	now := time.Now()
+	engine.now = func() time.Time { return now }
+	engine.SetRules([]AlertRule{{Name: "errors", Enabled: true, Color: "red", Cooldown: 30}})
+
+	msg := &DumpMessage{Color: "red"}
+	if len(engine.MatchDump(msg)) != 1 {
+		t.Fatal("First match should fire")
+	}
+
+	now = now.Add(10 * time.Second)
+	if len(engine.MatchDump(msg)) != 0 {
+		t.Error("Match within cooldown should not fire")
+	}
+
+	now = now.Add(30 * time.Second)
+	if len(engine.MatchDump(msg)) != 1 {
+		t.Error("Match after cooldown should fire")
+	}
+}
+
+// TestAlertEngine_MatchLog tests rules against log entries
+func TestAlertEngine_MatchLog(t *testing.T) {
+	engine := NewAlertEngine()
+	engine.SetRules([]AlertRule{
+		{Name: "log errors", Enabled: true, Source: AlertSourceLog, Color: "ERROR"},
+		{Name: "dump label", Enabled: true, Label: "payment"},
+		{Name: "deadlock", Enabled: true, Contains: "deadlock"},
+	})
+
+	events := engine.MatchLog(LogEntry{FileName: "laravel.log", Line: "SQLSTATE: Deadlock found", Level: "ERROR"})
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Rule != "log errors" || events[0].Title != "VersaDumps: laravel.log" {
+		t.Errorf("Unexpected event: %+v", events[0])
+	}
+	if events[1].Rule != "deadlock" || events[1].Source != AlertSourceLog {
+		t.Errorf("Unexpected event: %+v", events[1])
+	}
+}
+
+// TestValidateAlertRules tests rejected rule sets
+func TestValidateAlertRules(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rules []AlertRule
+	}{
+		{"missing name", []AlertRule{{Label: "x"}}},
+		{"duplicate name", []AlertRule{{Name: "a", Label: "x"}, {Name: "a", Color: "red"}}},
+		{"bad source", []AlertRule{{Name: "a", Source: "mail", Label: "x"}}},
+		{"no conditions", []AlertRule{{Name: "a"}}},
+		{"negative cooldown", []AlertRule{{Name: "a", Label: "x", Cooldown: -1}}},
+		{"bad predicate", []AlertRule{{Name: "a", Where: "balance < null"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidateAlertRules(tc.rules); err == nil {
+				t.Error("ValidateAlertRules() should fail")
+			}
+		})
+	}
+
+	if err := ValidateAlertRules(nil); err != nil {
+		t.Errorf("Empty rule set should be valid: %v", err)
+	}
+}
+
+// blockingNotifier holds every notification until release is closed
+type blockingNotifier struct {
+	shown   chan string
+	release chan struct{}
+}
+
+func (n *blockingNotifier) Notify(title, body string) error {
+	n.shown <- title
+	<-n.release
+	return nil
+}
+
+// TestNotificationQueue tests that a stuck notifier never blocks Send and extra notifications are dropped
+func TestNotificationQueue(t *testing.T) {
+	notifier := &blockingNotifier{shown: make(chan string, notificationQueueSize+2), release: make(chan struct{})}
+	queue := NewNotificationQueue(notifier, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		queue.Send("first", "")
+		// Wait for the worker to hold the first notification
+		<-notifier.shown
+		for i := 0; i < notificationQueueSize+5; i++ {
+			queue.Send("more", "")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Send() blocked on a stuck notifier")
+	}
+	if dropped := queue.Dropped(); dropped != 5 {
+		t.Errorf("Expected 5 dropped notifications, got %d", dropped)
+	}
+	close(notifier.release)
+}
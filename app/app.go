@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	gosys "runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -20,17 +24,49 @@ type App struct {
 	ctx            context.Context
 	messageCounter int
 	updateManager  *UpdateManager
-	httpServer     *http.Server
-	serverCancel   context.CancelFunc
-	logWatcher     *LogWatcher
-	serverMu       sync.Mutex // Protect server start/stop operations
+	profiles       *ProfileSupervisor // listeners and log watchers of the running profiles
+	store          *DumpStore         // nil unless a running profile keeps history
+	historyMu      sync.RWMutex
+	dumps          *DumpLog        // recently accepted dumps, queryable from Go
+	requests       *RequestTracker // dumps grouped by client request
+	delivery       *Deliverer      // frames dumps and log lines sent to the UI
+	checkpoints    *CheckpointRegistry
+	timers         *TimerAggregator
+	notifications  *NotificationQueue // desktop notifications of fired alerts
+	git            *GitResolver
+	share          *ShareHub
+	rejections     *RejectionCounter // requests refused by the access policy
+	metrics        *Metrics          // exposed on /metrics
+	startedAt      time.Time
+	uiAttached     atomic.Bool  // the webview has loaded the frontend
+	activeName     atomic.Value // name of the active profile, so polled endpoints need not read the config
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{
+	metrics := NewMetrics()
+	app := &App{
 		updateManager: NewUpdateManager(),
-	}
+		dumps:         NewDumpLog(defaultDumpRetention),
+		requests:      NewRequestTracker(),
+		checkpoints:   NewCheckpointRegistry(),
+		timers:        NewTimerAggregator(),
+		git:           NewGitResolver(),
+		share:         NewShareHub(),
+		rejections:    &RejectionCounter{},
+		metrics:       metrics,
+		startedAt:     time.Now(),
+	}
+	app.updateManager.metrics = metrics
+	app.profiles = NewProfileSupervisor(app.serveProfile, app.newProfileWatcher, app.configurePipeline)
+	app.delivery = NewDeliverer(func(event string, data interface{}) {
+		runtime.EventsEmit(app.ctx, event, data)
+	})
+	app.delivery.metrics = metrics
+	app.notifications = NewNotificationQueue(newNotifier(), func(err error) {
+		runtime.LogWarningf(app.ctx, "Desktop notification failed: %v", err)
+	})
+	return app
 }
 
 // startup is called when the app starts.
@@ -54,6 +90,7 @@ func (a *App) startup(ctx context.Context) {
 		runtime.LogErrorf(ctx, "No active profile found")
 		return
 	}
+	a.activeName.Store(activeProfile.Name)
 
 	// Emit loaded config to frontend so it can initialize theme/language
 	// Send the active profile as the config
@@ -67,7 +104,8 @@ func (a *App) startup(ctx context.Context) {
 	runtime.LogInfof(ctx, "Server: %s", activeProfile.Server)
 	runtime.LogInfof(ctx, "Port: %d", activeProfile.Port)
 	runtime.LogInfof(ctx, "════════════════════════════════════════")
-	a.startHTTPServer(activeProfile.Server, activeProfile.Port)
+	a.configureDelivery(activeProfile)
+	a.startHTTPServer(*activeProfile)
 
 	// Start log watcher if there are log folders configured
 	if len(activeProfile.LogFolders) > 0 {
@@ -76,6 +114,15 @@ func (a *App) startup(ctx context.Context) {
 		}
 	}
 
+	// Bring up the profiles that run alongside the active one
+	for _, name := range cfg.RunningProfiles {
+		if profile := cfg.GetProfile(name); profile != nil && name != activeProfile.Name {
+			if err := a.startProfile(*profile); err != nil {
+				runtime.LogErrorf(ctx, "Failed to start profile '%s': %v", name, err)
+			}
+		}
+	}
+
 	// Initialize window title with current counter
 	if a.ctx != nil {
 		runtime.WindowSetTitle(a.ctx, fmt.Sprintf("VersaDumps Visualizer (%d)", a.messageCounter))
@@ -179,12 +226,45 @@ func (a *App) SaveFrontendConfig(partial map[string]interface{}) error {
 		}
 	}
 
+	// Handle history field
+	if v, ok := partial["history"]; ok {
+		switch history := v.(type) {
+		case bool:
+			cfg.Profiles[profileIndex].History = history
+		case string:
+			cfg.Profiles[profileIndex].History = (history == "true")
+		}
+	}
+
+	// Handle auto_diff field
+	if v, ok := partial["auto_diff"]; ok {
+		switch autoDiff := v.(type) {
+		case bool:
+			cfg.Profiles[profileIndex].AutoDiff = autoDiff
+		case string:
+			cfg.Profiles[profileIndex].AutoDiff = (autoDiff == "true")
+		}
+	}
+
+	// Handle git_context field
+	if v, ok := partial["git_context"]; ok {
+		switch gitContext := v.(type) {
+		case bool:
+			cfg.Profiles[profileIndex].GitContext = gitContext
+		case string:
+			cfg.Profiles[profileIndex].GitContext = (gitContext == "true")
+		}
+	}
+
 	// Save the configuration
 	err = SaveConfig(cfg)
 	if err != nil {
 		return err
 	}
 
+	a.profiles.Configure(cfg.Profiles[profileIndex])
+	a.syncHistory()
+
 	// Only restart HTTP server if the server address or port changed
 	newServer := cfg.Profiles[profileIndex].Server
 	newPort := cfg.Profiles[profileIndex].Port
@@ -329,46 +409,83 @@ func (a *App) GetCurrentVersion() string {
 	return CurrentVersion
 }
 
-// startHTTPServer starts the HTTP server and stores its reference
-func (a *App) startHTTPServer(host string, port int) {
-	a.serverMu.Lock()
-	defer a.serverMu.Unlock()
-
-	// Stop existing server if running
-	a.stopHTTPServerInternal()
+// startHTTPServer starts the HTTP server for profile, replacing the one
+// the profile already runs
+func (a *App) startHTTPServer(profile Profile) {
+	if err := a.profiles.StartServer(a.ctx, profile); err != nil {
+		runtime.LogErrorf(a.ctx, "HTTP server not started: %v", err)
+		return
+	}
+	runtime.LogInfof(a.ctx, "HTTP server started successfully")
+}
 
-	// Create context for the server
-	ctx, cancel := context.WithCancel(a.ctx)
-	a.serverCancel = cancel
+// stopHTTPServer stops the listeners and log watchers of every profile
+func (a *App) stopHTTPServer() {
+	a.profiles.StopAll()
+}
 
-	// Start server (StartServer now manages its own goroutine)
-	a.httpServer = StartServer(ctx, host, port, a)
+// serveProfile starts the listener of a profile for the supervisor
+func (a *App) serveProfile(ctx context.Context, profile Profile, pipeline *profilePipeline) *http.Server {
+	return StartServer(ctx, profile, pipeline, a)
+}
 
-	runtime.LogInfof(a.ctx, "HTTP server started successfully")
+// newProfileWatcher creates the log watcher of a profile for the supervisor;
+// its entries are tagged with the profile name and matched against the
+// profile's alert rules
+func (a *App) newProfileWatcher(profile Profile, pipeline *profilePipeline) (*LogWatcher, error) {
+	watcher, err := NewLogWatcher(a.ctx)
+	if err != nil {
+		return nil, err
+	}
+	watcher.profile = profile.Name
+	watcher.delivery = a.delivery
+	watcher.metrics = a.metrics
+	watcher.onEntry = func(entry LogEntry) {
+		a.fireAlerts(pipeline.alerts.MatchLog(entry))
+		if encoded, err := json.Marshal(entry); err == nil {
+			a.share.Broadcast("log", encoded)
+		}
+	}
+	return watcher, nil
 }
 
-// stopHTTPServer stops the current HTTP server
-func (a *App) stopHTTPServer() {
-	a.serverMu.Lock()
-	defer a.serverMu.Unlock()
-	a.stopHTTPServerInternal()
+// startProfile starts the listener and log watcher of profile
+func (a *App) startProfile(profile Profile) error {
+	if err := a.profiles.StartServer(a.ctx, profile); err != nil {
+		return err
+	}
+	return a.profiles.StartWatcher(profile)
 }
 
-// stopHTTPServerInternal stops the server without locking (internal use only)
-func (a *App) stopHTTPServerInternal() {
-	if a.serverCancel != nil {
-		runtime.LogInfof(a.ctx, "Stopping HTTP server...")
-		a.serverCancel()
-		a.serverCancel = nil
+// configurePipeline applies the settings of profile to its pipeline for the
+// supervisor, opening the history store if the profile keeps history
+func (a *App) configurePipeline(profile Profile, pipeline *profilePipeline) {
+	pipeline.labels.SetPinned(profile.PinnedLabels)
+	pipeline.services.SetMuted(profile.MutedServices)
+	a.configureDedupe(&profile, pipeline)
+	a.applyAlertRules(&profile, pipeline)
+	pipeline.autoDiff.Store(profile.AutoDiff)
+	pipeline.gitContext.Store(profile.GitContext)
+	pipeline.history.Store(profile.History)
+	maxDumps, maxAge := profileHistoryRetention(profile)
+	pipeline.maxDumps.Store(int64(maxDumps))
+	pipeline.maxAge.Store(int64(maxAge))
+	if profile.History {
+		a.openHistory()
+	}
+}
 
-		// Graceful shutdown — wait up to 3 seconds for in-flight requests to complete
-		if a.httpServer != nil {
-			shutCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-			defer cancel()
-			_ = a.httpServer.Shutdown(shutCtx)
-			a.httpServer = nil
+// activePipeline returns the pipeline of the active profile. If the active
+// profile is not running it returns an empty pipeline, so getters report
+// nothing and setters only take effect through the saved config.
+func (a *App) activePipeline() *profilePipeline {
+	name, err := a.GetActiveProfileName()
+	if err == nil {
+		if pipeline := a.profiles.Pipeline(name); pipeline != nil {
+			return pipeline
 		}
 	}
+	return newProfilePipeline(name)
 }
 
 // RestartHTTPServer restarts the HTTP server with new configuration
@@ -381,15 +498,114 @@ func (a *App) RestartHTTPServer() error {
 	activeProfile := cfg.GetActiveProfile()
 	if activeProfile != nil {
 		runtime.LogInfof(a.ctx, "Restarting HTTP server with new config: %s:%d", activeProfile.Server, activeProfile.Port)
-		a.startHTTPServer(activeProfile.Server, activeProfile.Port)
+		a.startHTTPServer(*activeProfile)
 	}
 
 	return nil
 }
 
+// CheckServerHealth reports whether the HTTP server of the active profile
+// answers on /health. The check runs in Go so it can send the profile's
+// bearer token.
+func (a *App) CheckServerHealth() bool {
+	name, err := a.activeProfileName()
+	if err != nil {
+		return false
+	}
+	return a.profileHealthy(name)
+}
+
+// profileHealthy reports whether the listener of profile name answers on /health
+func (a *App) profileHealthy(name string) bool {
+	server, profile := a.profiles.Server(name)
+	if server == nil {
+		return false
+	}
+	token, socket := profile.AuthToken, profile.Socket
+
+	if server.Addr == "" {
+		// TCP is disabled; the socket speaks plain HTTP
+		if socket == "" {
+			return false
+		}
+		return healthOK(unixHTTPClient(socket, 2*time.Second), "http://versadumps/health", token)
+	}
+
+	host, port, err := net.SplitHostPort(server.Addr)
+	if err != nil {
+		return false
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	scheme := "http"
+	client := &http.Client{Timeout: 2 * time.Second}
+	if server.TLSConfig != nil {
+		scheme = "https"
+		leaf := server.TLSConfig.Certificates[0].Certificate[0]
+		client.Transport = &http.Transport{TLSClientConfig: pinnedTLSConfig(leaf)}
+	}
+
+	return healthOK(client, scheme+"://"+net.JoinHostPort(host, port)+"/health", token)
+}
+
+// healthOK reports whether url answers 200 when requested with token.
+func healthOK(client *http.Client, url, token string) bool {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// configureDelivery applies the delivery policy and rate of profile
+func (a *App) configureDelivery(profile *Profile) {
+	if err := a.delivery.Configure(profile.DeliveryPolicy, profile.DeliveryRate); err != nil {
+		runtime.LogErrorf(a.ctx, "%v; using the default delivery policy", err)
+		a.delivery.Configure("", profile.DeliveryRate)
+	}
+}
+
+// GetDeliveryStats returns how many messages reached the UI, how many were
+// coalesced into frames and how many were dropped
+func (a *App) GetDeliveryStats() DeliveryStats {
+	return a.delivery.Stats()
+}
+
+// writeMetrics writes the recorded metrics followed by the delivery counters
+// read at scrape time
+func (a *App) writeMetrics(w io.Writer) {
+	a.metrics.WriteTo(w)
+
+	stats := a.delivery.Stats()
+	writeMetricSample(w, "versadumps_ui_messages_delivered_total", "counter", "Messages delivered to the UI.", float64(stats.Delivered))
+	writeMetricSample(w, "versadumps_ui_messages_dropped_total", "counter", "Messages dropped because the UI fell behind.", float64(stats.Dropped))
+	writeMetricSample(w, "versadumps_ui_frames_total", "counter", "Frames emitted to the UI.", float64(stats.Frames))
+	writeMetricSample(w, "versadumps_ui_messages_pending", "gauge", "Messages waiting for the next frame.", float64(stats.Pending))
+	writeMetricSample(w, "versadumps_dumps_retained", "gauge", "Dumps retained for queries.", float64(a.dumps.Len()))
+}
+
+// GetRejectionStats returns how many requests the access policy refused
+func (a *App) GetRejectionStats() RejectionStats {
+	return a.rejections.Stats()
+}
+
 // GetLogWatcherStatus exposes the current log watcher status to the frontend
 func (a *App) GetLogWatcherStatus() (map[string]interface{}, error) {
-	if a.logWatcher == nil {
+	var watcher *LogWatcher
+	if name, err := a.GetActiveProfileName(); err == nil {
+		watcher = a.profiles.Watcher(name)
+	}
+	if watcher == nil {
 		return map[string]interface{}{
 			"running":     false,
 			"folderCount": 0,
@@ -397,7 +613,7 @@ func (a *App) GetLogWatcherStatus() (map[string]interface{}, error) {
 		}, nil
 	}
 	// Use the watcher internal status if available
-	status := a.logWatcher.GetStatus()
+	status := watcher.GetStatus()
 	return status, nil
 }
 
@@ -423,6 +639,20 @@ func (a *App) GetActiveProfileName() (string, error) {
 	return cfg.ActiveProfile, nil
 }
 
+// activeProfileName returns the name of the active profile as kept in
+// memory, reading the config only until startup or a switch has set it.
+func (a *App) activeProfileName() (string, error) {
+	if name, ok := a.activeName.Load().(string); ok {
+		return name, nil
+	}
+	name, err := a.GetActiveProfileName()
+	if err != nil {
+		return "", err
+	}
+	a.activeName.Store(name)
+	return name, nil
+}
+
 // CreateProfile creates a new profile with the given configuration
 func (a *App) CreateProfile(name string, server string, port int, theme string, lang string, showTypes bool) error {
 	cfg, err := LoadConfig()
@@ -486,7 +716,12 @@ func (a *App) DeleteProfile(name string) error {
 	}
 
 	cfg.Profiles = newProfiles
-	return SaveConfig(cfg)
+	cfg.setRunningProfile(name, false)
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+	a.profiles.Stop(name)
+	return nil
 }
 
 // SwitchProfile changes the active profile
@@ -509,30 +744,39 @@ func (a *App) SwitchProfile(name string) error {
 		return fmt.Errorf("profile '%s' not found", name)
 	}
 
+	previous := cfg.ActiveProfile
 	cfg.ActiveProfile = name
 
 	if err := SaveConfig(cfg); err != nil {
 		return err
 	}
+	a.activeName.Store(name)
 
-	// Restart HTTP server with new profile settings
+	// The previous profile keeps running only if it runs in the background
+	if previous != name && !cfg.IsRunningProfile(previous) {
+		a.profiles.Stop(previous)
+	}
+
+	a.configureDelivery(newProfile)
+
+	// Restart HTTP server with new profile settings; this also applies
+	// them to the profile's pipeline
 	if err := a.RestartHTTPServer(); err != nil {
 		return err
 	}
+	// Close the history store if no running profile keeps history anymore
+	a.syncHistory()
 
-	// Restart log watcher with new profile's log folders
-	if len(newProfile.LogFolders) > 0 {
-		if err := a.RestartLogWatcher(); err != nil {
-			runtime.LogErrorf(a.ctx, "Error restarting log watcher after profile switch: %v", err)
-		}
-	} else {
-		// Stop log watcher if new profile has no log folders
-		a.StopLogWatcher()
+	// Restart log watcher with new profile's log folders; a profile
+	// without folders gets none
+	if err := a.RestartLogWatcher(); err != nil {
+		runtime.LogErrorf(a.ctx, "Error restarting log watcher after profile switch: %v", err)
 	}
 
 	// Emit after all services have restarted so frontend reflects stable state
 	cfgBytes, _ := json.Marshal(newProfile)
 	runtime.EventsEmit(a.ctx, "profileSwitched", string(cfgBytes))
+	runtime.EventsEmit(a.ctx, "profilesChanged", a.GetRunningProfiles())
 
 	return nil
 }
@@ -566,16 +810,73 @@ func (a *App) UpdateProfile(name string, server string, port int, theme string,
 		return err
 	}
 
-	// If this is the active profile, restart server
-	if cfg.ActiveProfile == name {
-		return a.RestartHTTPServer()
+	// Restart the server if the profile is running
+	if a.profiles.IsRunning(name) {
+		if profile := cfg.GetProfile(name); profile != nil {
+			return a.profiles.StartServer(a.ctx, *profile)
+		}
+	}
+
+	return nil
+}
+
+// StartProfile runs a profile alongside the active one, with its own
+// listener and log watcher, and keeps it running across restarts
+func (a *App) StartProfile(name string) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	profile := cfg.GetProfile(name)
+	if profile == nil {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+
+	if err := a.startProfile(*profile); err != nil {
+		return err
+	}
+	if name != cfg.ActiveProfile {
+		cfg.setRunningProfile(name, true)
+		if err := SaveConfig(cfg); err != nil {
+			return err
+		}
+	}
+	runtime.EventsEmit(a.ctx, "profilesChanged", a.GetRunningProfiles())
+	return nil
+}
+
+// StopProfile stops a profile running alongside the active one
+func (a *App) StopProfile(name string) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if name == cfg.ActiveProfile {
+		return fmt.Errorf("cannot stop the active profile, switch to another profile first")
 	}
 
+	a.profiles.Stop(name)
+	a.syncHistory()
+	cfg.setRunningProfile(name, false)
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+	runtime.EventsEmit(a.ctx, "profilesChanged", a.GetRunningProfiles())
 	return nil
 }
 
+// GetRunningProfiles lists the profiles with a listener or log watcher
+func (a *App) GetRunningProfiles() []ProfileStatus {
+	active, _ := a.GetActiveProfileName()
+	return a.profiles.Status(active)
+}
+
 // AddLogFolder adds a log folder to a profile
 func (a *App) AddLogFolder(profileName string, path string, extensions []string, filters []string, format string) error {
+	if !validLogFormat(format) {
+		return fmt.Errorf("unknown log format '%s', expected text or json", format)
+	}
+
 	cfg, err := LoadConfig()
 	if err != nil {
 		return err
@@ -606,7 +907,7 @@ func (a *App) AddLogFolder(profileName string, path string, extensions []string,
 
 			// Default format to "text" if not specified
 			if format == "" {
-				format = "text"
+				format = LogFormatText
 			}
 
 			// Add folder
@@ -623,10 +924,10 @@ func (a *App) AddLogFolder(profileName string, path string, extensions []string,
 				return err
 			}
 
-			// Restart log watcher if this is the active profile
-			if cfg.ActiveProfile == profileName {
+			// Restart log watcher if the profile is running
+			if a.profiles.IsRunning(profileName) {
 				runtime.LogInfof(a.ctx, "Restarting log watcher after adding folder")
-				if err := a.RestartLogWatcher(); err != nil {
+				if err := a.profiles.StartWatcher(cfg.Profiles[i]); err != nil {
 					runtime.LogErrorf(a.ctx, "Error restarting log watcher: %v", err)
 				}
 			}
@@ -661,10 +962,10 @@ func (a *App) RemoveLogFolder(profileName string, path string) error {
 				return err
 			}
 
-			// Restart log watcher if this is the active profile
-			if cfg.ActiveProfile == profileName {
+			// Restart log watcher if the profile is running
+			if a.profiles.IsRunning(profileName) {
 				runtime.LogInfof(a.ctx, "Restarting log watcher after removing folder")
-				if err := a.RestartLogWatcher(); err != nil {
+				if err := a.profiles.StartWatcher(cfg.Profiles[i]); err != nil {
 					runtime.LogErrorf(a.ctx, "Error restarting log watcher: %v", err)
 				}
 			}
@@ -694,9 +995,9 @@ func (a *App) ToggleLogFolder(profileName string, path string, enabled bool) err
 						return err
 					}
 
-					// Restart log watcher if this is the active profile
-					if cfg.ActiveProfile == profileName {
-						return a.RestartLogWatcher()
+					// Restart log watcher if the profile is running
+					if a.profiles.IsRunning(profileName) {
+						return a.profiles.StartWatcher(cfg.Profiles[i])
 					}
 					return nil
 				}
@@ -709,6 +1010,10 @@ func (a *App) ToggleLogFolder(profileName string, path string, enabled bool) err
 
 // UpdateLogFolder updates the configuration of an existing log folder
 func (a *App) UpdateLogFolder(profileName string, path string, extensions []string, filters []string, format string) error {
+	if !validLogFormat(format) {
+		return fmt.Errorf("unknown log format '%s', expected text or json", format)
+	}
+
 	cfg, err := LoadConfig()
 	if err != nil {
 		return err
@@ -721,7 +1026,7 @@ func (a *App) UpdateLogFolder(profileName string, path string, extensions []stri
 				if cfg.Profiles[i].LogFolders[j].Path == path {
 					// Default format to "text" if not specified
 					if format == "" {
-						format = "text"
+						format = LogFormatText
 					}
 
 					// Update extensions, filters, and format
@@ -733,10 +1038,10 @@ func (a *App) UpdateLogFolder(profileName string, path string, extensions []stri
 						return err
 					}
 
-					// Restart log watcher if this is the active profile
-					if cfg.ActiveProfile == profileName {
+					// Restart log watcher if the profile is running
+					if a.profiles.IsRunning(profileName) {
 						runtime.LogInfof(a.ctx, "Restarting log watcher after updating folder")
-						if err := a.RestartLogWatcher(); err != nil {
+						if err := a.profiles.StartWatcher(cfg.Profiles[i]); err != nil {
 							runtime.LogErrorf(a.ctx, "Error restarting log watcher: %v", err)
 						}
 					}
@@ -751,6 +1056,83 @@ func (a *App) UpdateLogFolder(profileName string, path string, extensions []stri
 	return fmt.Errorf("profile '%s' not found", profileName)
 }
 
+// SetLogFolderFields sets the JSON keys read as level, message, timestamp and
+// context for a json folder; empty lists keep the Monolog/zap/pino/logrus defaults
+func (a *App) SetLogFolderFields(profileName string, path string, fields LogFieldMapping) error {
+	return a.updateLogFolder(profileName, path, func(folder *LogFolder) {
+		if len(fields.Level)+len(fields.Message)+len(fields.Timestamp)+len(fields.Context) == 0 {
+			folder.Fields = nil
+		} else {
+			folder.Fields = &fields
+		}
+	})
+}
+
+// SetLogFolderEntryStart joins continuation lines into multi-line entries:
+// pattern is a regex or a preset (bracket_date, laravel, iso_date, syslog,
+// json) matching the first line of an entry, "" for one entry per line, and
+// flushMs how long the last entry of a file waits for more lines
+func (a *App) SetLogFolderEntryStart(profileName string, path string, pattern string, flushMs int) error {
+	if _, err := entryStartPattern(pattern); err != nil {
+		return err
+	}
+	if flushMs < 0 {
+		return fmt.Errorf("flush timeout must not be negative")
+	}
+	return a.updateLogFolder(profileName, path, func(folder *LogFolder) {
+		folder.EntryStart = pattern
+		folder.EntryFlushMs = flushMs
+	})
+}
+
+// SetLogFolderParser sets how a text folder's lines are parsed: a preset
+// (nginx_access, nginx_error, apache_access, apache_error, php_fpm_slow,
+// laravel, symfony), a regex with named groups (timestamp, level, channel,
+// message, context, or any other field), or "" to detect levels by keyword
+func (a *App) SetLogFolderParser(profileName string, path string, parser string) error {
+	if _, err := compileLogParser(parser); err != nil {
+		return err
+	}
+	return a.updateLogFolder(profileName, path, func(folder *LogFolder) {
+		folder.Parser = parser
+	})
+}
+
+// updateLogFolder applies update to a folder of a profile, saves the config
+// and restarts the profile's log watcher if it is running
+func (a *App) updateLogFolder(profileName string, path string, update func(*LogFolder)) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	for i := range cfg.Profiles {
+		if cfg.Profiles[i].Name != profileName {
+			continue
+		}
+		for j := range cfg.Profiles[i].LogFolders {
+			if cfg.Profiles[i].LogFolders[j].Path != path {
+				continue
+			}
+			update(&cfg.Profiles[i].LogFolders[j])
+			if err := SaveConfig(cfg); err != nil {
+				return err
+			}
+
+			// Restart log watcher if the profile is running
+			if a.profiles.IsRunning(profileName) {
+				if err := a.profiles.StartWatcher(cfg.Profiles[i]); err != nil {
+					runtime.LogErrorf(a.ctx, "Error restarting log watcher: %v", err)
+				}
+			}
+			return nil
+		}
+		return fmt.Errorf("folder '%s' not found in profile", path)
+	}
+
+	return fmt.Errorf("profile '%s' not found", profileName)
+}
+
 // ========================================
 // Log Watcher Control Functions
 // ========================================
@@ -767,32 +1149,14 @@ func (a *App) StartLogWatcher() error {
 		return fmt.Errorf("no active profile")
 	}
 
-	// Stop existing watcher if running
-	if a.logWatcher != nil {
-		a.logWatcher.Stop()
-	}
-
-	// Create new watcher
-	watcher, err := NewLogWatcher(a.ctx)
-	if err != nil {
-		return err
-	}
-
-	a.logWatcher = watcher
-
-	// Start monitoring folders from active profile
-	if len(activeProfile.LogFolders) > 0 {
-		return a.logWatcher.Start(activeProfile.LogFolders)
-	}
-
-	return nil
+	// Replaces the watcher the profile already runs
+	return a.profiles.StartWatcher(*activeProfile)
 }
 
-// StopLogWatcher stops the log watcher
+// StopLogWatcher stops the log watcher of the active profile
 func (a *App) StopLogWatcher() {
-	if a.logWatcher != nil {
-		a.logWatcher.Stop()
-		a.logWatcher = nil
+	if name, err := a.GetActiveProfileName(); err == nil {
+		a.profiles.StopWatcher(name)
 	}
 }
 
@@ -861,6 +1225,8 @@ func (a *App) GetWindowPosition() (*WindowPosition, error) {
 
 // domReady is called after front-end resources have been loaded
 func (a *App) domReady(ctx context.Context) {
+	a.uiAttached.Store(true)
+
 	// Restore window position if saved
 	if pos, err := a.GetWindowPosition(); err == nil && pos != nil {
 		// Only restore if position seems valid (not off-screen)
@@ -874,25 +1240,819 @@ func (a *App) domReady(ctx context.Context) {
 // beforeClose is called before the application terminates
 func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 	runtime.LogInfof(ctx, "Application closing, cleaning up resources...")
+	a.uiAttached.Store(false)
 
 	// Save window position before closing
 	if err := a.SaveWindowPosition(); err != nil {
 		runtime.LogErrorf(ctx, "Failed to save window position: %v", err)
 	}
 
-	// Stop log watcher if running
-	if a.logWatcher != nil {
-		runtime.LogInfof(ctx, "Stopping log watcher...")
-		a.logWatcher.Stop()
-	}
-
-	// Stop HTTP server
-	runtime.LogInfof(ctx, "Stopping HTTP server...")
+	// Stop the listeners and log watchers of every running profile
+	runtime.LogInfof(ctx, "Stopping HTTP servers and log watchers...")
 	a.stopHTTPServer()
 
+	// Close history database
+	a.closeHistory()
+
+	// Disconnect share viewers
+	a.share.Stop()
+
 	runtime.LogInfof(ctx, "Cleanup complete")
 	return false
 }
 
+// enrichDump adds backend-side context to a parsed message before it is
+// encoded: the profile it arrived on and, if that profile asks for it, the
+// git state of the file it was sent from.
+func (a *App) enrichDump(pipeline *profilePipeline, msg *DumpMessage) {
+	msg.Profile = pipeline.name
+	if pipeline.gitContext.Load() && msg.Frame != nil {
+		msg.Git = a.git.Resolve(msg.Frame.File, msg.Frame.Line)
+	}
+}
+
+// acceptDump runs every accepted /data message through the backend and
+// queues it for the UI: it is retained for queries and persisted if history
+// is on before it is emitted. It reports whether the message reached the UI
+// as a new row, which is not the case for muted or filtered-out services nor
+// for repeats. Everything it sends the UI goes through the deliverer, so a
+// dump loop cannot flood the webview. Mutes, dedupe, labels, diffs and
+// alerts are those of the profile whose pipeline received the message.
+func (a *App) acceptDump(pipeline *profilePipeline, msg *DumpMessage, payload []byte) bool {
+	a.dumps.Add(msg)
+	a.profiles.CountMessage(pipeline.name)
+	a.recordHistory(pipeline, msg, payload)
+
+	if record := a.requests.AddDump(msg); record != nil {
+		a.delivery.Push("requestUpdated", record)
+	}
+
+	deliver, discovered := pipeline.services.Observe(msg.Service, msg.ReceivedAt)
+	if discovered {
+		a.delivery.Push("serviceDiscovered", pipeline.services.Summary(msg.Service))
+	}
+	if !deliver {
+		return false
+	}
+
+	// Repeats only update the row of their first occurrence
+	if repeat := pipeline.dedupe.Observe(msg); repeat != nil {
+		a.delivery.Push("dumpRepeated", repeat)
+		return false
+	}
+
+	// Queued as a JSON string ahead of the events that refer to it; the
+	// deliverer coalesces bursts into frames
+	a.delivery.Push("newData", string(payload))
+
+	previous := pipeline.labels.Add(msg)
+	if msg.Label != "" && pipeline.labels.IsPinned(msg.Label) {
+		a.delivery.Push("pinnedLabelUpdated", pipeline.labels.Summary(msg.Label))
+	}
+
+	if previous != nil && pipeline.autoDiff.Load() {
+		diff, err := DiffDumpMessages(previous, msg)
+		if err != nil {
+			runtime.LogWarningf(a.ctx, "Failed to diff dumps %d and %d: %v", previous.ID, msg.ID, err)
+		} else if len(diff.Changes) > 0 {
+			a.delivery.Push("dumpDiff", diff)
+		}
+	}
+
+	a.fireAlerts(pipeline.alerts.MatchDump(msg))
+	a.share.Broadcast("dump", payload)
+	return true
+}
+
+// QueryDumps filters the retained dumps of the active profile by label,
+// color, file, time range, free text and JSON path predicates, returning a
+// page newest first
+func (a *App) QueryDumps(query DumpQuery) (*DumpQueryResult, error) {
+	profileName, err := a.activeProfileName()
+	if err != nil {
+		return nil, err
+	}
+	query.Profile = profileName
+	return a.queryDumps(query)
+}
+
+// queryDumps runs query over the dumps of query.Profile, which callers set:
+// its stored history when the profile keeps one, else the dumps retained in
+// memory this session. The result says which was searched.
+func (a *App) queryDumps(query DumpQuery) (*DumpQueryResult, error) {
+	if pipeline := a.profiles.Pipeline(query.Profile); pipeline != nil && pipeline.history.Load() {
+		a.historyMu.RLock()
+		defer a.historyMu.RUnlock()
+		if a.store != nil {
+			messages, err := a.store.Messages(query.Profile, query)
+			if err != nil {
+				return nil, err
+			}
+			return runDumpQueryOn(messages, query, QuerySourceHistory)
+		}
+	}
+	return runDumpQueryOn(a.dumps.Snapshot(), query, QuerySourceSession)
+}
+
+// runDumpQueryOn runs query over messages, tagging the result with source.
+func runDumpQueryOn(messages []*DumpMessage, query DumpQuery, source string) (*DumpQueryResult, error) {
+	result, err := RunDumpQuery(messages, query)
+	if err != nil {
+		return nil, err
+	}
+	result.Source = source
+	return result, nil
+}
+
+// ========================================
+// Label Functions
+// ========================================
+
+// ListLabels returns every label seen this session, pinned labels first
+func (a *App) ListLabels() []LabelSummary {
+	return a.activePipeline().labels.Summaries(false)
+}
+
+// GetPinnedLabels returns the latest dump of each pinned label
+func (a *App) GetPinnedLabels() []LabelSummary {
+	return a.activePipeline().labels.Summaries(true)
+}
+
+// GetLabelHistory returns the retained dumps for a label, newest first
+func (a *App) GetLabelHistory(label string) []*DumpMessage {
+	return a.activePipeline().labels.History(label)
+}
+
+// PinLabel pins a label in the active profile
+func (a *App) PinLabel(label string) error {
+	return a.setLabelPinned(label, true)
+}
+
+// UnpinLabel unpins a label in the active profile
+func (a *App) UnpinLabel(label string) error {
+	return a.setLabelPinned(label, false)
+}
+
+// setLabelPinned updates the active profile's pinned labels and the index.
+func (a *App) setLabelPinned(label string, pinned bool) error {
+	if label == "" {
+		return fmt.Errorf("empty label")
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	labels := []string{}
+	for _, l := range activeProfile.PinnedLabels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	if pinned {
+		labels = append(labels, label)
+	}
+	activeProfile.PinnedLabels = labels
+
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	return nil
+}
+
+// ========================================
+// Service Functions
+// ========================================
+
+// GetServices returns every service seen this session with its dump count
+func (a *App) GetServices() []ServiceSummary {
+	return a.activePipeline().services.Summaries()
+}
+
+// MuteService stops dumps from a service reaching the UI in the active profile
+func (a *App) MuteService(service string) error {
+	return a.setServiceMuted(service, true)
+}
+
+// UnmuteService lets dumps from a service reach the UI again
+func (a *App) UnmuteService(service string) error {
+	return a.setServiceMuted(service, false)
+}
+
+// setServiceMuted updates the active profile's muted services and the registry.
+func (a *App) setServiceMuted(service string, muted bool) error {
+	service = normalizeService(service)
+	if service == "" {
+		return fmt.Errorf("empty service")
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	services := []string{}
+	for _, s := range activeProfile.MutedServices {
+		if s != service {
+			services = append(services, s)
+		}
+	}
+	if muted {
+		services = append(services, service)
+	}
+	activeProfile.MutedServices = services
+
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	return nil
+}
+
+// SetServiceFilter shows only dumps from the given services for this
+// session; an empty list shows every service
+func (a *App) SetServiceFilter(services []string) {
+	a.activePipeline().services.SetFocus(services)
+}
+
+// GetServiceFilter returns the services the UI is limited to, if any
+func (a *App) GetServiceFilter() []string {
+	return a.activePipeline().services.Focus()
+}
+
+// ========================================
+// Request Functions
+// ========================================
+
+// ListRequests returns the tracked client requests of the active profile,
+// most recent first
+func (a *App) ListRequests() ([]RequestRecord, error) {
+	profileName, err := a.activeProfileName()
+	if err != nil {
+		return nil, err
+	}
+	return a.requests.List(profileName), nil
+}
+
+// GetRequestDumps returns the retained dumps of one client request of the
+// active profile, oldest first
+func (a *App) GetRequestDumps(id string) ([]*DumpMessage, error) {
+	profileName, err := a.activeProfileName()
+	if err != nil {
+		return nil, err
+	}
+	if a.requests.Get(profileName, id) == nil {
+		return nil, fmt.Errorf("request '%s' not found", id)
+	}
+	dumps := []*DumpMessage{}
+	for _, msg := range a.dumps.Snapshot() {
+		if msg.Profile == profileName && msg.RequestID == id {
+			dumps = append(dumps, msg)
+		}
+	}
+	return dumps, nil
+}
+
+// ClearRequests discards the tracked requests
+func (a *App) ClearRequests() {
+	a.requests.Reset()
+}
+
+// ========================================
+// Dedupe Functions
+// ========================================
+
+// configureDedupe applies the dedupe key and window of profile to its pipeline
+func (a *App) configureDedupe(profile *Profile, pipeline *profilePipeline) {
+	window := time.Duration(profile.DedupeWindow) * time.Second
+	if err := pipeline.dedupe.Configure(profile.Dedupe, window); err != nil {
+		runtime.LogErrorf(a.ctx, "Profile '%s': %v; deduplication is off", profile.Name, err)
+		pipeline.dedupe.Configure(DedupeOff, 0)
+	}
+}
+
+// GetDedupe returns the dedupe key and window in seconds of the active profile
+func (a *App) GetDedupe() (map[string]interface{}, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return nil, fmt.Errorf("no active profile found")
+	}
+	return map[string]interface{}{
+		"key":    activeProfile.Dedupe,
+		"window": activeProfile.DedupeWindow,
+	}, nil
+}
+
+// SetDedupe sets how repeated dumps are collapsed on the active profile: key
+// is "", "label", "location" or "content", and windowSeconds 0 dedupes for
+// the whole session
+func (a *App) SetDedupe(key string, windowSeconds int) error {
+	if err := NewDeduper().Configure(key, time.Duration(windowSeconds)*time.Second); err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	activeProfile.Dedupe = key
+	activeProfile.DedupeWindow = windowSeconds
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	return nil
+}
+
+// ResetDedupe forgets the dumps seen so far, so the next of each shows as a
+// new row
+func (a *App) ResetDedupe() {
+	a.activePipeline().dedupe.Reset()
+}
+
+// ========================================
+// Checkpoint Functions
+// ========================================
+
+// ListCheckpoints returns the checkpoints whose clients are still waiting
+func (a *App) ListCheckpoints() []Checkpoint {
+	return a.checkpoints.List()
+}
+
+// ResumeCheckpoint lets the client blocked on a checkpoint continue
+func (a *App) ResumeCheckpoint(id string) error {
+	return a.checkpoints.Resolve(id, CheckpointContinue)
+}
+
+// AbortCheckpoint tells the client blocked on a checkpoint to abort
+func (a *App) AbortCheckpoint(id string) error {
+	return a.checkpoints.Resolve(id, CheckpointAbort)
+}
+
+// ========================================
+// Benchmark Functions
+// ========================================
+
+// GetBenchmarks returns the aggregated timer records, most recently updated first
+func (a *App) GetBenchmarks() []BenchmarkRecord {
+	return a.timers.Records()
+}
+
+// ResetBenchmarks discards all timer statistics
+func (a *App) ResetBenchmarks() {
+	a.timers.Reset()
+}
+
+// ========================================
+// Alert Functions
+// ========================================
+
+// applyAlertRules loads profile's alert rules into the engine of its pipeline.
+func (a *App) applyAlertRules(profile *Profile, pipeline *profilePipeline) {
+	if err := pipeline.alerts.SetRules(profile.AlertRules); err != nil {
+		runtime.LogErrorf(a.ctx, "Invalid alert rules in profile '%s': %v", profile.Name, err)
+		pipeline.alerts.SetRules(nil)
+	}
+}
+
+// fireAlerts queues a native notification and notifies the frontend for each
+// event. It never waits for the notification to be shown.
+func (a *App) fireAlerts(events []AlertEvent) {
+	for _, ev := range events {
+		runtime.LogInfof(a.ctx, "Alert rule '%s' fired (%s)", ev.Rule, ev.Source)
+		if !a.notifications.Send(ev.Title, ev.Body) {
+			runtime.LogWarningf(a.ctx, "Desktop notification of alert '%s' dropped: too many pending", ev.Rule)
+		}
+		a.delivery.Push("alertFired", ev)
+	}
+}
+
+// GetAlertRules returns the alert rules of the active profile
+func (a *App) GetAlertRules() ([]AlertRule, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return nil, fmt.Errorf("no active profile found")
+	}
+
+	if activeProfile.AlertRules == nil {
+		return []AlertRule{}, nil
+	}
+	return activeProfile.AlertRules, nil
+}
+
+// SaveAlertRules validates and stores the alert rules of the active profile
+func (a *App) SaveAlertRules(rules []AlertRule) error {
+	if err := ValidateAlertRules(rules); err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	activeProfile.AlertRules = rules
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	return nil
+}
+
+// ========================================
+// Diff Functions
+// ========================================
+
+// lookupDump finds a dump in the session log, falling back to history.
+func (a *App) lookupDump(id int64) (*DumpMessage, error) {
+	if msg := a.dumps.Get(id); msg != nil {
+		return msg, nil
+	}
+
+	a.historyMu.RLock()
+	defer a.historyMu.RUnlock()
+	if a.store != nil {
+		rec, err := a.store.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			var msg DumpMessage
+			if err := json.Unmarshal([]byte(rec.Payload), &msg); err != nil {
+				return nil, fmt.Errorf("dump %d: %v", id, err)
+			}
+			return &msg, nil
+		}
+	}
+	return nil, fmt.Errorf("dump %d not found", id)
+}
+
+// DiffDumps returns the structural diff between the contexts of two dumps
+func (a *App) DiffDumps(idA int64, idB int64) (*DumpDiff, error) {
+	from, err := a.lookupDump(idA)
+	if err != nil {
+		return nil, err
+	}
+	to, err := a.lookupDump(idB)
+	if err != nil {
+		return nil, err
+	}
+	return DiffDumpMessages(from, to)
+}
+
+// GetAutoDiff reports whether consecutive dumps sharing a label are diffed
+func (a *App) GetAutoDiff() bool {
+	return a.activePipeline().autoDiff.Load()
+}
+
+// SetAutoDiff turns automatic diffing of consecutive same-label dumps on or off
+func (a *App) SetAutoDiff(enabled bool) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	activeProfile.AutoDiff = enabled
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	return nil
+}
+
+// ========================================
+// Git Functions
+// ========================================
+
+// GetGitContext reports whether dumps are enriched with git information
+func (a *App) GetGitContext() bool {
+	return a.activePipeline().gitContext.Load()
+}
+
+// SetGitContext turns git enrichment of incoming dumps on or off
+func (a *App) SetGitContext(enabled bool) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	activeProfile.GitContext = enabled
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	return nil
+}
+
+// ========================================
+// Share Functions
+// ========================================
+
+// StartShareSession starts streaming dumps and log lines to read-only
+// viewers and returns the token-protected link to share
+func (a *App) StartShareSession() (*ShareSession, error) {
+	if err := a.share.Start(); err != nil {
+		return nil, err
+	}
+	session := a.GetShareSession()
+	if session == nil {
+		a.share.Stop()
+		return nil, fmt.Errorf("the HTTP server is not running")
+	}
+	runtime.LogInfof(a.ctx, "Share session started: %s", session.URL)
+	return session, nil
+}
+
+// StopShareSession ends the share session and disconnects every viewer
+func (a *App) StopShareSession() {
+	a.share.Stop()
+	runtime.LogInfof(a.ctx, "Share session stopped")
+}
+
+// GetShareSession returns the active share session, or nil
+func (a *App) GetShareSession() *ShareSession {
+	token, startedAt, viewers := a.share.Active()
+	if token == "" {
+		return nil
+	}
+
+	// Use the address the active profile is actually listening on
+	addr, secure := "", false
+	if name, err := a.GetActiveProfileName(); err == nil {
+		if server, _ := a.profiles.Server(name); server != nil {
+			addr, secure = server.Addr, server.TLSConfig != nil
+		}
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+	base := net.JoinHostPort(shareHost(host), port)
+	httpScheme, wsScheme := "http", "ws"
+	if secure {
+		httpScheme, wsScheme = "https", "wss"
+	}
+	return &ShareSession{
+		Token:     token,
+		URL:       httpScheme + "://" + base + "/share?token=" + token,
+		WSURL:     wsScheme + "://" + base + "/share/ws?token=" + token,
+		StartedAt: startedAt,
+		Viewers:   viewers,
+	}
+}
+
+// ========================================
+// TLS Functions
+// ========================================
+
+// GetCACertificate returns the local VersaDumps CA in PEM format, creating
+// it if needed, so clients can trust the generated server certificates
+func (a *App) GetCACertificate() (string, error) {
+	dir, err := getTLSDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	if _, _, err := loadOrCreateCA(dir); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, caCertFile))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ExportCACertificate saves the local CA to a file chosen by the user and
+// returns its path, or an empty string if the dialog was cancelled
+func (a *App) ExportCACertificate() (string, error) {
+	caPEM, err := a.GetCACertificate()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export VersaDumps CA",
+		DefaultFilename: "versadumps-ca.crt",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Certificates (*.crt;*.pem)", Pattern: "*.crt;*.pem"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, []byte(caPEM), 0644); err != nil {
+		return "", fmt.Errorf("failed to export CA: %v", err)
+	}
+	runtime.LogInfof(a.ctx, "CA certificate exported to %s", path)
+	return path, nil
+}
+
+// ========================================
+// History Functions
+// ========================================
+
+// openHistory opens the history store if it is not open yet.
+func (a *App) openHistory() {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	if a.store != nil {
+		return
+	}
+
+	path, err := getHistoryPath()
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to resolve history path: %v", err)
+		return
+	}
+	store, err := OpenDumpStore(path)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to open history: %v", err)
+		return
+	}
+	a.store = store
+	runtime.LogInfof(a.ctx, "Dump history enabled: %s", path)
+}
+
+// syncHistory closes the history store once no running profile keeps
+// history; profiles that do open it when they are configured.
+func (a *App) syncHistory() {
+	if !a.profiles.KeepsHistory() {
+		a.closeHistory()
+	}
+}
+
+// closeHistory closes the history store if it is open.
+func (a *App) closeHistory() {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	if a.store != nil {
+		a.store.Close()
+		a.store = nil
+	}
+}
+
+// recordHistory saves an accepted dump if the profile of pipeline keeps
+// history.
+func (a *App) recordHistory(pipeline *profilePipeline, msg *DumpMessage, payload []byte) {
+	if !pipeline.history.Load() {
+		return
+	}
+	a.historyMu.RLock()
+	defer a.historyMu.RUnlock()
+	if a.store == nil {
+		return
+	}
+	if err := a.store.Save(pipeline.name, msg, payload); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to save dump to history: %v", err)
+		return
+	}
+
+	// Prune on the first save and then every historyPruneInterval saves
+	if pipeline.saved.Add(1)%historyPruneInterval != 1 {
+		return
+	}
+	maxDumps, maxAge := int(pipeline.maxDumps.Load()), time.Duration(pipeline.maxAge.Load())
+	if _, err := a.store.Prune(pipeline.name, maxDumps, maxAge); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to prune history: %v", err)
+	}
+}
+
+// historyStore returns the open store or an error if history is disabled.
+// Callers must hold historyMu.
+func (a *App) historyStore() (*DumpStore, error) {
+	if a.store == nil {
+		return nil, fmt.Errorf("history is not enabled for the active profile")
+	}
+	return a.store, nil
+}
+
+// SetHistoryEnabled turns dump persistence on or off for the active profile
+func (a *App) SetHistoryEnabled(enabled bool) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	activeProfile := cfg.GetActiveProfile()
+	if activeProfile == nil {
+		return fmt.Errorf("no active profile found")
+	}
+
+	activeProfile.History = enabled
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	a.profiles.Configure(*activeProfile)
+	a.syncHistory()
+	return nil
+}
+
+// GetHistory returns a page of stored dumps for the active profile, newest first
+func (a *App) GetHistory(page int, pageSize int) (*HistoryPage, error) {
+	return a.SearchHistory("", page, pageSize)
+}
+
+// SearchHistory returns a page of stored dumps whose label, file or payload contains query
+func (a *App) SearchHistory(query string, page int, pageSize int) (*HistoryPage, error) {
+	profileName, err := a.GetActiveProfileName()
+	if err != nil {
+		return nil, err
+	}
+
+	a.historyMu.RLock()
+	defer a.historyMu.RUnlock()
+	store, err := a.historyStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(profileName, query, page, pageSize)
+}
+
+// DeleteHistoryEntry removes a single stored dump of the active profile
+func (a *App) DeleteHistoryEntry(id int64) error {
+	profileName, err := a.GetActiveProfileName()
+	if err != nil {
+		return err
+	}
+
+	a.historyMu.RLock()
+	defer a.historyMu.RUnlock()
+	store, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	return store.Delete(profileName, id)
+}
+
+// ClearHistory removes every stored dump of the active profile
+func (a *App) ClearHistory() error {
+	profileName, err := a.GetActiveProfileName()
+	if err != nil {
+		return err
+	}
+
+	a.historyMu.RLock()
+	defer a.historyMu.RUnlock()
+	store, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	return store.Clear(profileName)
+}
+
 // Implementations for SetTaskbarBadge are platform-specific and live in
 // files guarded by build tags (badge_windows.go, badge_darwin.go, badge_unix.go).
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxBatchItems bounds the number of messages accepted by one /data/batch request.
+const maxBatchItems = 1000
+
+// BatchItemResult reports the outcome of one message in a batch. Index is
+// the position in the JSON array, or the line number (from 1) for NDJSON.
+type BatchItemResult struct {
+	Index int    `json:"index"`
+	OK    bool   `json:"ok"`
+	ID    int64  `json:"id,omitempty"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BatchReport is the /data/batch response body.
+type BatchReport struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Results  []BatchItemResult `json:"results"`
+}
+
+// batchItem is one raw message of a batch and its position.
+type batchItem struct {
+	index int
+	raw   []byte
+}
+
+// splitDumpBatch splits a batch body into raw messages. A body starting
+// with '[' is a JSON array; anything else is read as newline-delimited
+// JSON, skipping blank lines. Items are validated separately by the caller.
+func splitDumpBatch(body []byte) ([]batchItem, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, &PayloadError{Message: "batch is empty"}
+	}
+
+	var items []batchItem
+	if trimmed[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, &PayloadError{Message: fmt.Sprintf("invalid JSON array: %v", err)}
+		}
+		for i, raw := range raws {
+			items = append(items, batchItem{index: i, raw: raw})
+		}
+	} else {
+		for i, line := range bytes.Split(trimmed, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			items = append(items, batchItem{index: i + 1, raw: line})
+		}
+	}
+
+	if len(items) > maxBatchItems {
+		return nil, &PayloadError{Message: fmt.Sprintf("batch has %d items, the limit is %d", len(items), maxBatchItems)}
+	}
+	return items, nil
+}
+
+// ParseDumpBatch validates every message of a batch on its own, applying
+// the depth and key limits to each. It returns the accepted messages and a
+// report with one result per item.
+func ParseDumpBatch(body []byte, limits PayloadLimits) ([]*DumpMessage, *BatchReport, error) {
+	items, err := splitDumpBatch(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &BatchReport{Results: make([]BatchItemResult, 0, len(items))}
+	messages := make([]*DumpMessage, 0, len(items))
+	for _, item := range items {
+		result := BatchItemResult{Index: item.index}
+		msg, err := ParseDumpMessageLimited(bytes.NewReader(item.raw), limits)
+		if err != nil {
+			var payloadErr *PayloadError
+			if errors.As(err, &payloadErr) {
+				result.Field, result.Error = payloadErr.Field, payloadErr.Message
+			} else {
+				result.Error = err.Error()
+			}
+			report.Rejected++
+		} else {
+			result.OK = true
+			result.ID = msg.ID
+			messages = append(messages, msg)
+			report.Accepted++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return messages, report, nil
+}
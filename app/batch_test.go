@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestParseDumpBatch tests JSON array and NDJSON batches with per-item results
+func TestParseDumpBatch(t *testing.T) {
+	testCases := []struct {
+		name            string
+		body            string
+		expectedIndexes []int
+		expectedOK      []bool
+	}{
+		{
+			name:            "json array",
+			body:            `[{"context": 1}, {"context": 2, "frame": {"line": "x"}}, {"context": 3}]`,
+			expectedIndexes: []int{0, 1, 2},
+			expectedOK:      []bool{true, false, true},
+		},
+		{
+			name:            "ndjson",
+			body:            "{\"context\": 1}\n\n{not json}\r\n{\"context\": {\"a\": 1}}\n",
+			expectedIndexes: []int{1, 3, 4},
+			expectedOK:      []bool{true, false, true},
+		},
+		{
+			name:            "single ndjson line",
+			body:            `{"context": "only"}`,
+			expectedIndexes: []int{1},
+			expectedOK:      []bool{true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			messages, report, err := ParseDumpBatch([]byte(tc.body), defaultPayloadLimits())
+			if err != nil {
+				t.Fatalf("ParseDumpBatch() failed: %v", err)
+			}
+			if len(report.Results) != len(tc.expectedOK) {
+				t.Fatalf("Expected %d results, got %+v", len(tc.expectedOK), report.Results)
+			}
+
+			accepted := 0
+			for i, result := range report.Results {
+				if result.Index != tc.expectedIndexes[i] || result.OK != tc.expectedOK[i] {
+					t.Errorf("Result %d: unexpected %+v", i, result)
+				}
+				if result.OK {
+					accepted++
+					if result.ID == 0 || result.Error != "" {
+						t.Errorf("Accepted item should have an id and no error: %+v", result)
+					}
+				} else if result.Error == "" {
+					t.Errorf("Rejected item should explain why: %+v", result)
+				}
+			}
+			if report.Accepted != accepted || report.Rejected != len(tc.expectedOK)-accepted || len(messages) != accepted {
+				t.Errorf("Unexpected counts: %+v with %d messages", report, len(messages))
+			}
+		})
+	}
+}
+
+// TestParseDumpBatch_FieldErrors tests that item errors keep the offending field
+func TestParseDumpBatch_FieldErrors(t *testing.T) {
+	_, report, err := ParseDumpBatch([]byte(`[{"context": 1, "frame": {"line": "x"}}]`), defaultPayloadLimits())
+	if err != nil {
+		t.Fatalf("ParseDumpBatch() failed: %v", err)
+	}
+	if report.Results[0].Field != "frame.line" {
+		t.Errorf("Expected field 'frame.line', got %+v", report.Results[0])
+	}
+}
+
+// TestParseDumpBatch_Invalid tests batches rejected as a whole
+func TestParseDumpBatch_Invalid(t *testing.T) {
+	tooMany := strings.Repeat(`{"context": 1}`+"\n", maxBatchItems+1)
+
+	for _, body := range []string{"", "  \n ", `[{"context": 1}`, tooMany} {
+		if _, _, err := ParseDumpBatch([]byte(body), defaultPayloadLimits()); err == nil {
+			t.Errorf("ParseDumpBatch(%s) should fail", fmt.Sprintf("%.40q", body))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CheckpointDecision is the answer returned to a client blocked on /checkpoint.
+type CheckpointDecision string
+
+const (
+	CheckpointContinue CheckpointDecision = "continue"
+	CheckpointAbort    CheckpointDecision = "abort"
+)
+
+const (
+	defaultCheckpointTimeout = 5 * time.Minute
+	maxCheckpointTimeout     = time.Hour
+)
+
+// Checkpoint is a paused client waiting for the developer to continue or abort.
+type Checkpoint struct {
+	ID        string             `json:"id"`
+	Label     string             `json:"label,omitempty"`
+	Dump      *DumpMessage       `json:"dump,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	Deadline  time.Time          `json:"deadline"`
+	OnTimeout CheckpointDecision `json:"on_timeout"`
+}
+
+// CheckpointResult is sent back to the waiting client and to the frontend.
+type CheckpointResult struct {
+	ID       string             `json:"id"`
+	Decision CheckpointDecision `json:"decision"`
+	TimedOut bool               `json:"timed_out,omitempty"`
+}
+
+type pendingCheckpoint struct {
+	checkpoint Checkpoint
+	decision   chan CheckpointDecision
+}
+
+// CheckpointRegistry tracks checkpoints whose clients are still blocked.
+type CheckpointRegistry struct {
+	mu      sync.Mutex
+	pending map[string]*pendingCheckpoint
+}
+
+// NewCheckpointRegistry creates an empty registry.
+func NewCheckpointRegistry() *CheckpointRegistry {
+	return &CheckpointRegistry{pending: make(map[string]*pendingCheckpoint)}
+}
+
+// NewCheckpoint builds a checkpoint for dump that expires after timeout.
+// A zero timeout uses the default; longer timeouts are capped.
+func NewCheckpoint(dump *DumpMessage, timeout time.Duration, onTimeout CheckpointDecision) Checkpoint {
+	if timeout <= 0 {
+		timeout = defaultCheckpointTimeout
+	}
+	if timeout > maxCheckpointTimeout {
+		timeout = maxCheckpointTimeout
+	}
+	if onTimeout != CheckpointAbort {
+		onTimeout = CheckpointContinue
+	}
+	now := time.Now()
+	cp := Checkpoint{
+		ID:        newCheckpointID(),
+		Dump:      dump,
+		CreatedAt: now,
+		Deadline:  now.Add(timeout),
+		OnTimeout: onTimeout,
+	}
+	if dump != nil {
+		cp.Label = dump.Label
+	}
+	return cp
+}
+
+// Register adds cp to the registry. The returned channel receives the decision
+// once Resolve is called for cp.ID.
+func (r *CheckpointRegistry) Register(cp Checkpoint) <-chan CheckpointDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := &pendingCheckpoint{checkpoint: cp, decision: make(chan CheckpointDecision, 1)}
+	r.pending[cp.ID] = p
+	return p.decision
+}
+
+// Resolve delivers decision to the client waiting on checkpoint id.
+func (r *CheckpointRegistry) Resolve(id string, decision CheckpointDecision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pending[id]
+	if !ok {
+		return fmt.Errorf("checkpoint '%s' not found", id)
+	}
+	delete(r.pending, id)
+	p.decision <- decision
+	return nil
+}
+
+// Remove forgets checkpoint id without delivering a decision, e.g. because
+// the client disconnected. It reports whether the checkpoint was pending.
+func (r *CheckpointRegistry) Remove(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.pending[id]
+	delete(r.pending, id)
+	return ok
+}
+
+// List returns the pending checkpoints, oldest first.
+func (r *CheckpointRegistry) List() []Checkpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Checkpoint, 0, len(r.pending))
+	for _, p := range r.pending {
+		out = append(out, p.checkpoint)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// ParseCheckpointRequest reads a /checkpoint body. It accepts the same fields
+// as /data (context is optional) plus "timeout" in seconds and "on_timeout"
+// ("continue" or "abort").
+func ParseCheckpointRequest(body []byte) (Checkpoint, error) {
+	obj, err := decodePayloadObject(body)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	timeoutSecs, err := optionalInt(obj, "timeout", "timeout")
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if timeoutSecs < 0 {
+		return Checkpoint{}, &PayloadError{Field: "timeout", Message: "must not be negative"}
+	}
+	onTimeout, err := optionalString(obj, "on_timeout", "on_timeout")
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	switch CheckpointDecision(onTimeout) {
+	case "", CheckpointContinue, CheckpointAbort:
+	default:
+		return Checkpoint{}, &PayloadError{Field: "on_timeout", Message: "must be 'continue' or 'abort'"}
+	}
+
+	dump, err := normalizeDumpFields(obj)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	return NewCheckpoint(dump, time.Duration(timeoutSecs)*time.Second, CheckpointDecision(onTimeout)), nil
+}
+
+func newCheckpointID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCheckpointRegistry_Resolve tests delivering a decision to a waiting client
+func TestCheckpointRegistry_Resolve(t *testing.T) {
+	registry := NewCheckpointRegistry()
+	cp := NewCheckpoint(nil, time.Minute, "")
+
+	decision := registry.Register(cp)
+	if len(registry.List()) != 1 {
+		t.Fatalf("Expected 1 pending checkpoint, got %d", len(registry.List()))
+	}
+
+	if err := registry.Resolve(cp.ID, CheckpointAbort); err != nil {
+		t.Fatalf("Resolve() failed: %v", err)
+	}
+
+	select {
+	case d := <-decision:
+		if d != CheckpointAbort {
+			t.Errorf("Expected abort, got %s", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Decision was not delivered")
+	}
+
+	if len(registry.List()) != 0 {
+		t.Error("Resolved checkpoint should no longer be pending")
+	}
+	if err := registry.Resolve(cp.ID, CheckpointContinue); err == nil {
+		t.Error("Resolving twice should fail")
+	}
+}
+
+// TestCheckpointRegistry_Remove tests dropping a checkpoint whose client went away
+func TestCheckpointRegistry_Remove(t *testing.T) {
+	registry := NewCheckpointRegistry()
+	first := NewCheckpoint(nil, 0, "")
+	second := NewCheckpoint(nil, 0, "")
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	registry.Register(second)
+	registry.Register(first)
+
+	list := registry.List()
+	if len(list) != 2 || list[0].ID != first.ID {
+		t.Errorf("List() should return checkpoints oldest first")
+	}
+
+	if !registry.Remove(first.ID) {
+		t.Error("Remove() should report a pending checkpoint")
+	}
+	if registry.Remove(first.ID) {
+		t.Error("Remove() should report false for an unknown checkpoint")
+	}
+}
+
+// TestNewCheckpoint_Timeouts tests default and capped timeouts
+func TestNewCheckpoint_Timeouts(t *testing.T) {
+	cp := NewCheckpoint(nil, 0, "bogus")
+	if d := cp.Deadline.Sub(cp.CreatedAt); d != defaultCheckpointTimeout {
+		t.Errorf("Expected default timeout, got %v", d)
+	}
+	if cp.OnTimeout != CheckpointContinue {
+		t.Errorf("Expected continue on timeout, got %s", cp.OnTimeout)
+	}
+
+	cp = NewCheckpoint(nil, 48*time.Hour, CheckpointAbort)
+	if d := cp.Deadline.Sub(cp.CreatedAt); d != maxCheckpointTimeout {
+		t.Errorf("Expected capped timeout, got %v", d)
+	}
+	if cp.OnTimeout != CheckpointAbort {
+		t.Errorf("Expected abort on timeout, got %s", cp.OnTimeout)
+	}
+}
+
+// TestParseCheckpointRequest tests reading checkpoint bodies
+func TestParseCheckpointRequest(t *testing.T) {
+	cp, err := ParseCheckpointRequest([]byte(`{"label": "before save", "timeout": 30, "on_timeout": "abort", "frame": {"file": "/a.php", "line": 3}}`))
+	if err != nil {
+		t.Fatalf("ParseCheckpointRequest() failed: %v", err)
+	}
+	if cp.Label != "before save" || cp.Dump == nil || cp.Dump.Frame.Line != 3 {
+		t.Errorf("Unexpected checkpoint: %+v", cp)
+	}
+	if d := cp.Deadline.Sub(cp.CreatedAt); d != 30*time.Second {
+		t.Errorf("Expected 30s timeout, got %v", d)
+	}
+
+	for _, body := range []string{`{"timeout": -1}`, `{"on_timeout": "later"}`, `[]`} {
+		if _, err := ParseCheckpointRequest([]byte(body)); err == nil {
+			t.Errorf("ParseCheckpointRequest(%s) should fail", body)
+		}
+	}
+}
@@ -9,22 +9,51 @@ import (
 
 // LogFolder represents a folder to monitor for log files
 type LogFolder struct {
-	Path       string   `yaml:"path" json:"path"`
-	Extensions []string `yaml:"extensions" json:"extensions"` // e.g., ["*.log", "*.txt"]
-	Filters    []string `yaml:"filters" json:"filters"`       // e.g., ["error", "warning", "info"]
-	Enabled    bool     `yaml:"enabled" json:"enabled"`
-	Format     string   `yaml:"format,omitempty" json:"format,omitempty"` // "text" or "json"
+	Path         string           `yaml:"path" json:"path"`
+	Extensions   []string         `yaml:"extensions" json:"extensions"` // e.g., ["*.log", "*.txt"]
+	Filters      []string         `yaml:"filters" json:"filters"`       // e.g., ["error", "warning", "info"]
+	Enabled      bool             `yaml:"enabled" json:"enabled"`
+	Format       string           `yaml:"format,omitempty" json:"format,omitempty"`                 // "text" or "json"
+	Fields       *LogFieldMapping `yaml:"fields,omitempty" json:"fields,omitempty"`                 // JSON keys of level, message, timestamp and context
+	EntryStart   string           `yaml:"entry_start,omitempty" json:"entry_start,omitempty"`       // regex or preset matching the first line of a multi-line entry
+	EntryFlushMs int              `yaml:"entry_flush_ms,omitempty" json:"entry_flush_ms,omitempty"` // release the last entry after this much quiet; default 1000
+	Parser       string           `yaml:"parser,omitempty" json:"parser,omitempty"`                 // preset or regex with named groups, for text folders
 }
 
 // Profile represents a configuration profile
 type Profile struct {
-	Name       string      `yaml:"name" json:"name"`
-	Server     string      `yaml:"server" json:"server"`
-	Port       int         `yaml:"port" json:"port"`
-	Theme      string      `yaml:"theme,omitempty" json:"theme,omitempty"`
-	Lang       string      `yaml:"language,omitempty" json:"language,omitempty"`
-	ShowTypes  bool        `yaml:"show_types,omitempty" json:"show_types,omitempty"`
-	LogFolders []LogFolder `yaml:"log_folders,omitempty" json:"log_folders,omitempty"`
+	Name           string      `yaml:"name" json:"name"`
+	Server         string      `yaml:"server" json:"server"`
+	Port           int         `yaml:"port" json:"port"`
+	Theme          string      `yaml:"theme,omitempty" json:"theme,omitempty"`
+	Lang           string      `yaml:"language,omitempty" json:"language,omitempty"`
+	ShowTypes      bool        `yaml:"show_types,omitempty" json:"show_types,omitempty"`
+	LogFolders     []LogFolder `yaml:"log_folders,omitempty" json:"log_folders,omitempty"`
+	History        bool        `yaml:"history,omitempty" json:"history,omitempty"`             // persist dumps to history.db
+	HistoryLimit   int         `yaml:"history_limit,omitempty" json:"history_limit,omitempty"` // stored dumps kept, default 10000
+	HistoryDays    int         `yaml:"history_days,omitempty" json:"history_days,omitempty"`   // stored dumps older than this are pruned, default 30
+	PinnedLabels   []string    `yaml:"pinned_labels,omitempty" json:"pinned_labels,omitempty"`
+	MutedServices  []string    `yaml:"muted_services,omitempty" json:"muted_services,omitempty"`
+	AlertRules     []AlertRule `yaml:"alert_rules,omitempty" json:"alert_rules,omitempty"`
+	AutoDiff       bool        `yaml:"auto_diff,omitempty" json:"auto_diff,omitempty"`     // diff consecutive dumps sharing a label
+	GitContext     bool        `yaml:"git_context,omitempty" json:"git_context,omitempty"` // attach branch, commit and blame to dumps
+	AuthToken      string      `yaml:"auth_token,omitempty" json:"auth_token,omitempty"`   // required as "Authorization: Bearer <token>" when set
+	AllowedIPs     []string    `yaml:"allowed_ips,omitempty" json:"allowed_ips,omitempty"` // IPs or CIDRs; loopback is always allowed
+	CORSOrigins    []string    `yaml:"cors_origins,omitempty" json:"cors_origins,omitempty"`
+	TLS            bool        `yaml:"tls,omitempty" json:"tls,omitempty"`           // serve HTTPS
+	TLSCert        string      `yaml:"tls_cert,omitempty" json:"tls_cert,omitempty"` // PEM files; the local CA issues one when empty
+	TLSKey         string      `yaml:"tls_key,omitempty" json:"tls_key,omitempty"`
+	Socket         string      `yaml:"socket,omitempty" json:"socket,omitempty"`           // Unix domain socket path served alongside TCP
+	SocketMode     string      `yaml:"socket_mode,omitempty" json:"socket_mode,omitempty"` // octal permissions, default 0600
+	SocketGroup    string      `yaml:"socket_group,omitempty" json:"socket_group,omitempty"`
+	DisableTCP     bool        `yaml:"disable_tcp,omitempty" json:"disable_tcp,omitempty"` // serve only on Socket
+	MaxBodyMB      int         `yaml:"max_body_mb,omitempty" json:"max_body_mb,omitempty"` // decompressed request body limit, default 10
+	MaxJSONDepth   int         `yaml:"max_json_depth,omitempty" json:"max_json_depth,omitempty"`
+	MaxJSONKeys    int         `yaml:"max_json_keys,omitempty" json:"max_json_keys,omitempty"`     // object members and array items per dump
+	DeliveryPolicy string      `yaml:"delivery_policy,omitempty" json:"delivery_policy,omitempty"` // drop_oldest, drop_newest or summarize
+	DeliveryRate   int         `yaml:"delivery_rate,omitempty" json:"delivery_rate,omitempty"`     // messages per second sent to the UI
+	Dedupe         string      `yaml:"dedupe,omitempty" json:"dedupe,omitempty"`                   // label, location or content
+	DedupeWindow   int         `yaml:"dedupe_window,omitempty" json:"dedupe_window,omitempty"`     // seconds; 0 dedupes for the whole session
 }
 
 // WindowPosition stores window position and size
@@ -37,9 +66,10 @@ type WindowPosition struct {
 
 // Config holds the application configuration with multiple profiles
 type Config struct {
-	ActiveProfile  string          `yaml:"active_profile" json:"active_profile"`
-	Profiles       []Profile       `yaml:"profiles" json:"profiles"`
-	WindowPosition *WindowPosition `yaml:"window_position,omitempty" json:"window_position,omitempty"`
+	ActiveProfile   string          `yaml:"active_profile" json:"active_profile"`
+	RunningProfiles []string        `yaml:"running_profiles,omitempty" json:"running_profiles,omitempty"` // started alongside the active profile
+	Profiles        []Profile       `yaml:"profiles" json:"profiles"`
+	WindowPosition  *WindowPosition `yaml:"window_position,omitempty" json:"window_position,omitempty"`
 }
 
 // GetActiveProfile returns the currently active profile
@@ -56,6 +86,41 @@ func (c *Config) GetActiveProfile() *Profile {
 	return nil
 }
 
+// GetProfile returns the profile called name, or nil
+func (c *Config) GetProfile(name string) *Profile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// IsRunningProfile reports whether name runs alongside the active profile
+func (c *Config) IsRunningProfile(name string) bool {
+	for _, running := range c.RunningProfiles {
+		if running == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setRunningProfile adds or removes name from the profiles started
+// alongside the active one
+func (c *Config) setRunningProfile(name string, running bool) {
+	kept := make([]string, 0, len(c.RunningProfiles)+1)
+	for _, n := range c.RunningProfiles {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	if running {
+		kept = append(kept, name)
+	}
+	c.RunningProfiles = kept
+}
+
 // ConfigDirFunc is a variable that can be overridden in tests
 var ConfigDirFunc = os.UserConfigDir
 
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Dedupe keys: which messages count as repeats of each other.
+const (
+	DedupeOff      = ""
+	DedupeLabel    = "label"    // same label
+	DedupeLocation = "location" // same file:line
+	DedupeContent  = "content"  // same label, color and dumped value
+)
+
+// maxDedupeKeys bounds the first occurrences remembered for deduplication.
+const maxDedupeKeys = 5000
+
+// DumpRepeat is emitted as "dumpRepeated" when a message collapses into an
+// earlier one instead of being shown as a new row.
+type DumpRepeat struct {
+	ID          int64     `json:"id"` // ID of the first occurrence
+	RepeatID    int64     `json:"repeat_id"`
+	Key         string    `json:"key"`
+	Occurrences int       `json:"occurrences"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+type dedupeEntry struct {
+	firstID     int64
+	occurrences int
+	firstSeen   time.Time
+	lastSeen    time.Time
+	session     bool // kept for the whole session, as for ->once()
+}
+
+// Deduper collapses repeated messages by label, location or content, within
+// a time window or for the whole session. Messages flagged once are always
+// deduplicated by location for the whole session.
+type Deduper struct {
+	mu      sync.Mutex
+	mode    string
+	window  time.Duration // 0 means the whole session
+	entries map[string]*dedupeEntry
+	order   []string // keys oldest first, for eviction
+}
+
+// NewDeduper creates a Deduper with deduplication off.
+func NewDeduper() *Deduper {
+	return &Deduper{entries: make(map[string]*dedupeEntry)}
+}
+
+// Configure sets the dedupe key and window. Changing them forgets what was
+// seen so far.
+func (d *Deduper) Configure(mode string, window time.Duration) error {
+	switch mode {
+	case DedupeOff, DedupeLabel, DedupeLocation, DedupeContent:
+	default:
+		return fmt.Errorf("unknown dedupe key '%s', expected label, location or content", mode)
+	}
+	if window < 0 {
+		return fmt.Errorf("dedupe window must not be negative")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if mode != d.mode || window != d.window {
+		d.mode, d.window = mode, window
+		d.resetLocked()
+	}
+	return nil
+}
+
+// Reset forgets every message seen so far.
+func (d *Deduper) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resetLocked()
+}
+
+func (d *Deduper) resetLocked() {
+	d.entries = make(map[string]*dedupeEntry)
+	d.order = nil
+}
+
+// dedupeKey returns the key msg is deduplicated by, or "" if it is not.
+func dedupeKey(msg *DumpMessage, mode string) string {
+	switch mode {
+	case DedupeLabel:
+		if msg.Label != "" {
+			return "label:" + msg.Label
+		}
+	case DedupeLocation:
+		if msg.Frame != nil && msg.Frame.File != "" {
+			return fmt.Sprintf("location:%s:%d", msg.Frame.File, msg.Frame.Line)
+		}
+	case DedupeContent:
+		encoded, err := json.Marshal([]interface{}{msg.Label, msg.Color, msg.Context})
+		if err == nil {
+			sum := sha256.Sum256(encoded)
+			return "content:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return ""
+}
+
+// Observe records msg and returns a DumpRepeat when it repeats an earlier
+// message, or nil when it should be shown as a new row.
+func (d *Deduper) Observe(msg *DumpMessage) *DumpRepeat {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	session := msg.Once
+	key := ""
+	if msg.Once {
+		key = "once:" + dedupeKey(msg, DedupeLocation)
+		if key == "once:" {
+			key = "once:" + dedupeKey(msg, DedupeContent)
+		}
+	} else {
+		key = dedupeKey(msg, d.mode)
+		session = d.window == 0
+	}
+	if key == "" {
+		return nil
+	}
+
+	at := msg.ReceivedAt
+	if entry, ok := d.entries[key]; ok && (entry.session || at.Sub(entry.firstSeen) < d.window) {
+		entry.occurrences++
+		entry.lastSeen = at
+		return &DumpRepeat{
+			ID:          entry.firstID,
+			RepeatID:    msg.ID,
+			Key:         key,
+			Occurrences: entry.occurrences,
+			LastSeen:    at,
+		}
+	}
+
+	if _, ok := d.entries[key]; !ok {
+		if len(d.order) >= maxDedupeKeys {
+			delete(d.entries, d.order[0])
+			copy(d.order, d.order[1:])
+			d.order = d.order[:len(d.order)-1]
+		}
+		d.order = append(d.order, key)
+	}
+	// A new window starts with this message as its first occurrence
+	d.entries[key] = &dedupeEntry{firstID: msg.ID, occurrences: 1, firstSeen: at, lastSeen: at, session: session}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestDeduper_Keys tests which messages count as repeats for each key
+func TestDeduper_Keys(t *testing.T) {
+	at := time.Now()
+	first := &DumpMessage{ID: 1, Label: "user", Color: "info", Context: map[string]interface{}{"id": 1.0}, Frame: &DumpFrame{File: "a.php", Line: 10}, ReceivedAt: at}
+	sameLabel := &DumpMessage{ID: 2, Label: "user", Color: "info", Context: map[string]interface{}{"id": 2.0}, Frame: &DumpFrame{File: "b.php", Line: 3}, ReceivedAt: at}
+	sameLocation := &DumpMessage{ID: 3, Label: "order", Color: "red", Context: "x", Frame: &DumpFrame{File: "a.php", Line: 10}, ReceivedAt: at}
+	sameContent := &DumpMessage{ID: 4, Label: "user", Color: "info", Context: map[string]interface{}{"id": 1.0}, Frame: &DumpFrame{File: "c.php", Line: 7}, ReceivedAt: at}
+
+	testCases := []struct {
+		mode    string
+		repeats []bool // for sameLabel, sameLocation, sameContent
+	}{
+		{DedupeOff, []bool{false, false, false}},
+		{DedupeLabel, []bool{true, false, true}},
+		{DedupeLocation, []bool{false, true, false}},
+		{DedupeContent, []bool{false, false, true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run("mode="+tc.mode, func(t *testing.T) {
+			d := NewDeduper()
+			if err := d.Configure(tc.mode, 0); err != nil {
+				t.Fatalf("Configure() failed: %v", err)
+			}
+			if d.Observe(first) != nil {
+				t.Fatal("The first message should never be a repeat")
+			}
+			for i, msg := range []*DumpMessage{sameLabel, sameLocation, sameContent} {
+				repeat := d.Observe(msg)
+				if (repeat != nil) != tc.repeats[i] {
+					t.Errorf("Message %d: expected repeat=%v, got %+v", msg.ID, tc.repeats[i], repeat)
+				}
+				if repeat != nil && (repeat.ID != 1 || repeat.RepeatID != msg.ID) {
+					t.Errorf("Repeat should point at the first occurrence, got %+v", repeat)
+				}
+			}
+		})
+	}
+}
+
+// TestDeduper_Window tests that repeats outside the window start a new row
+func TestDeduper_Window(t *testing.T) {
+	d := NewDeduper()
+	d.Configure(DedupeLabel, time.Minute)
+	start := time.Now()
+
+	d.Observe(&DumpMessage{ID: 1, Label: "tick", ReceivedAt: start})
+	repeat := d.Observe(&DumpMessage{ID: 2, Label: "tick", ReceivedAt: start.Add(30 * time.Second)})
+	if repeat == nil || repeat.Occurrences != 2 || !repeat.LastSeen.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("Expected a second occurrence, got %+v", repeat)
+	}
+	if d.Observe(&DumpMessage{ID: 3, Label: "tick", ReceivedAt: start.Add(2 * time.Minute)}) != nil {
+		t.Fatal("A message past the window should show as a new row")
+	}
+	repeat = d.Observe(&DumpMessage{ID: 4, Label: "tick", ReceivedAt: start.Add(2*time.Minute + time.Second)})
+	if repeat == nil || repeat.ID != 3 || repeat.Occurrences != 2 {
+		t.Errorf("The new window should count from message 3, got %+v", repeat)
+	}
+
+	// Changing the configuration forgets what was seen
+	d.Configure(DedupeLabel, 0)
+	if d.Observe(&DumpMessage{ID: 5, Label: "tick", ReceivedAt: start}) != nil {
+		t.Error("Reconfiguring should reset the deduper")
+	}
+}
+
+// TestDeduper_Once tests that messages flagged once are deduplicated with dedupe off
+func TestDeduper_Once(t *testing.T) {
+	d := NewDeduper()
+	at := time.Now()
+	frame := &DumpFrame{File: "boot.php", Line: 4}
+
+	if d.Observe(&DumpMessage{ID: 1, Once: true, Context: "a", Frame: frame, ReceivedAt: at}) != nil {
+		t.Fatal("The first once message should be shown")
+	}
+	if d.Observe(&DumpMessage{ID: 2, Once: true, Context: "b", Frame: frame, ReceivedAt: at.Add(time.Hour)}) == nil {
+		t.Error("A once message from the same location should be a repeat for the whole session")
+	}
+	if d.Observe(&DumpMessage{ID: 3, Context: "a", Frame: frame, ReceivedAt: at}) != nil {
+		t.Error("Messages not flagged once should not be deduplicated with dedupe off")
+	}
+
+	d.Reset()
+	if d.Observe(&DumpMessage{ID: 4, Once: true, Frame: frame, ReceivedAt: at}) != nil {
+		t.Error("Reset should forget once messages")
+	}
+
+	msg, err := ParseDumpMessage([]byte(`{"context": 1, "metadata": {"once": true}}`))
+	if err != nil || !msg.Once {
+		t.Errorf("Expected metadata.once to be parsed, got %v, %v", msg, err)
+	}
+	if _, err := ParseDumpMessage([]byte(`{"context": 1, "once": "yes"}`)); err == nil {
+		t.Error("A non-boolean once should be rejected")
+	}
+}
+
+// TestDeduper_Limits tests eviction and configuration validation
+func TestDeduper_Limits(t *testing.T) {
+	d := NewDeduper()
+	d.Configure(DedupeLabel, 0)
+	at := time.Now()
+	for i := 0; i < maxDedupeKeys+1; i++ {
+		d.Observe(&DumpMessage{ID: int64(i), Label: fmt.Sprintf("l%d", i), ReceivedAt: at})
+	}
+	if d.Observe(&DumpMessage{Label: "l0", ReceivedAt: at}) != nil {
+		t.Error("The oldest key should have been evicted")
+	}
+	if d.Observe(&DumpMessage{Label: fmt.Sprintf("l%d", maxDedupeKeys), ReceivedAt: at}) == nil {
+		t.Error("Recent keys should be kept")
+	}
+
+	if err := d.Configure("frame", 0); err == nil {
+		t.Error("Unknown keys should be rejected")
+	}
+	if err := d.Configure(DedupeLabel, -time.Second); err == nil {
+		t.Error("Negative windows should be rejected")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Delivery policies for messages that do not fit the pending buffer.
+const (
+	DeliveryDropOldest = "drop_oldest" // keep the newest messages (default)
+	DeliveryDropNewest = "drop_newest" // keep what is already queued
+	DeliverySummarize  = "summarize"   // drop the oldest and tell the UI how many
+)
+
+// Delivery defaults: frames go out at most every deliveryInterval, and the
+// rate bounds how many messages a second reach the webview.
+const (
+	deliveryInterval    = 50 * time.Millisecond
+	defaultDeliveryRate = 1000
+)
+
+// frameEvents maps a per-message event to the event carrying a frame of them.
+var frameEvents = map[string]string{
+	"newData":      "newDataBatch",
+	"logLine":      "logLines",
+	"dumpRepeated": "dumpRepeatedBatch",
+}
+
+// deliveryOrder is the order frames go out in within a flush. Dumps come
+// first so a repeat never reaches the UI before the row it updates; events
+// not listed follow in name order.
+var deliveryOrder = []string{"newData", "dumpRepeated", "logLine"}
+
+// DeliveryStats counts what happened to the messages pushed to the UI.
+type DeliveryStats struct {
+	Pushed    uint64 `json:"pushed"`
+	Delivered uint64 `json:"delivered"`
+	Coalesced uint64 `json:"coalesced"` // delivered in a frame with others instead of on its own
+	Dropped   uint64 `json:"dropped"`
+	Frames    uint64 `json:"frames"`
+	Pending   int    `json:"pending"`
+	Policy    string `json:"policy"`
+	Rate      int    `json:"rate"`
+}
+
+// DroppedEvents is emitted as "eventsDropped" under the summarize policy.
+type DroppedEvents struct {
+	Event string `json:"event"`
+	Count int    `json:"count"`
+}
+
+// queuedItem is a pushed message, when it was pushed and its position
+// among all pushed messages.
+type queuedItem struct {
+	data interface{}
+	at   time.Time
+	seq  uint64
+}
+
+// Deliverer buffers high-volume UI events and flushes them in frames at a
+// bounded rate, so a dump loop or a noisy log cannot flood the webview.
+type Deliverer struct {
+	mu        sync.Mutex
+	emit      func(event string, data interface{})
+	queues    map[string][]queuedItem
+	dropped   map[string]int // dropped since the last frame, for summaries
+	policy    string
+	rate      int
+	scheduled bool
+	lastFlush time.Time
+	seq       uint64 // sequence number of the last pushed message
+	stats     DeliveryStats
+	metrics   *Metrics // records how long messages wait; optional
+}
+
+// NewDeliverer creates a Deliverer sending frames through emit.
+func NewDeliverer(emit func(event string, data interface{})) *Deliverer {
+	return &Deliverer{
+		emit:    emit,
+		queues:  make(map[string][]queuedItem),
+		dropped: make(map[string]int),
+		policy:  DeliveryDropOldest,
+		rate:    defaultDeliveryRate,
+	}
+}
+
+// validDeliveryPolicy reports whether policy is known; "" means the default.
+func validDeliveryPolicy(policy string) bool {
+	switch policy {
+	case "", DeliveryDropOldest, DeliveryDropNewest, DeliverySummarize:
+		return true
+	}
+	return false
+}
+
+// Configure sets the overflow policy and the maximum messages per second.
+// Zero values keep the defaults.
+func (d *Deliverer) Configure(policy string, rate int) error {
+	if !validDeliveryPolicy(policy) {
+		return fmt.Errorf("unknown delivery policy '%s'", policy)
+	}
+	if policy == "" {
+		policy = DeliveryDropOldest
+	}
+	if rate <= 0 {
+		rate = defaultDeliveryRate
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policy, d.rate = policy, rate
+	return nil
+}
+
+// frameSize is how many messages one frame may carry. Callers hold d.mu.
+func (d *Deliverer) frameSize() int {
+	n := int(int64(d.rate) * int64(deliveryInterval) / int64(time.Second))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// capacity is how many messages may wait per event: two seconds' worth.
+func (d *Deliverer) capacity() int {
+	return 2 * d.rate
+}
+
+// Push queues data for event and schedules a flush.
+func (d *Deliverer) Push(event string, data interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stats.Pushed++
+	queue := d.queues[event]
+	if len(queue) >= d.capacity() {
+		d.stats.Dropped++
+		d.dropped[event]++
+		if d.policy == DeliveryDropNewest {
+			return
+		}
+		queue = append(queue[:0], queue[1:]...)
+	}
+	d.seq++
+	d.queues[event] = append(queue, queuedItem{data: data, at: time.Now(), seq: d.seq})
+	d.scheduleLocked()
+}
+
+// scheduleLocked arranges a flush once the frame interval has passed.
+func (d *Deliverer) scheduleLocked() {
+	if d.scheduled {
+		return
+	}
+	d.scheduled = true
+	delay := deliveryInterval - time.Since(d.lastFlush)
+	if delay < 0 {
+		delay = 0
+	}
+	time.AfterFunc(delay, d.flush)
+}
+
+// flushOrder returns the queued events in delivery order. Callers hold d.mu.
+func (d *Deliverer) flushOrder() []string {
+	order := make([]string, 0, len(d.queues))
+	listed := make(map[string]bool, len(deliveryOrder))
+	for _, event := range deliveryOrder {
+		listed[event] = true
+		if len(d.queues[event]) > 0 {
+			order = append(order, event)
+		}
+	}
+	rest := []string{}
+	for event, queue := range d.queues {
+		if !listed[event] && len(queue) > 0 {
+			rest = append(rest, event)
+		}
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// flush emits one frame per event and reschedules while messages remain.
+// Frames go out in delivery order, and no message overtakes a dump pushed
+// before it that is still waiting for a later frame.
+func (d *Deliverer) flush() {
+	type frame struct {
+		event string
+		items []interface{}
+	}
+
+	d.mu.Lock()
+	d.scheduled = false
+	d.lastFlush = time.Now()
+	size := d.frameSize()
+	frames := []frame{}
+	summaries := []DroppedEvents{}
+	for _, event := range d.flushOrder() {
+		queue := d.queues[event]
+		before := uint64(math.MaxUint64)
+		if event != "newData" && len(d.queues["newData"]) > 0 {
+			before = d.queues["newData"][0].seq
+		}
+		n := 0
+		for n < len(queue) && n < size && queue[n].seq < before {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		items := make([]interface{}, n)
+		for i, item := range queue[:n] {
+			items[i] = item.data
+			d.metrics.Observe(metricEmitLatency, d.lastFlush.Sub(item.at).Seconds(), event)
+		}
+		d.queues[event] = append(queue[:0], queue[n:]...)
+		frames = append(frames, frame{event: event, items: items})
+
+		d.stats.Delivered += uint64(n)
+		d.stats.Frames++
+		if n > 1 {
+			d.stats.Coalesced += uint64(n)
+		}
+	}
+	for event, count := range d.dropped {
+		if d.policy == DeliverySummarize {
+			summaries = append(summaries, DroppedEvents{Event: event, Count: count})
+		}
+		delete(d.dropped, event)
+	}
+	for _, queue := range d.queues {
+		if len(queue) > 0 {
+			d.scheduleLocked()
+			break
+		}
+	}
+	d.mu.Unlock()
+
+	// Emit outside the lock; a single message keeps its own event
+	for _, f := range frames {
+		batchEvent, ok := frameEvents[f.event]
+		if len(f.items) == 1 || !ok {
+			for _, item := range f.items {
+				d.emit(f.event, item)
+			}
+			continue
+		}
+		d.emit(batchEvent, f.items)
+	}
+	for _, summary := range summaries {
+		d.emit("eventsDropped", summary)
+	}
+}
+
+// Stats returns the delivery counters.
+func (d *Deliverer) Stats() DeliveryStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats := d.stats
+	for _, queue := range d.queues {
+		stats.Pending += len(queue)
+	}
+	stats.Policy, stats.Rate = d.policy, d.rate
+	return stats
+}
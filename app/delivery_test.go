@@ -0,0 +1,213 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordedEvent is one event sent through a test deliverer
+type recordedEvent struct {
+	name string
+	data interface{}
+}
+
+// eventRecorder collects emitted events
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+func (r *eventRecorder) emit(event string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, recordedEvent{event, data})
+}
+
+func (r *eventRecorder) snapshot() []recordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]recordedEvent(nil), r.events...)
+}
+
+// waitDelivered waits until the deliverer has nothing pending
+func waitDelivered(t *testing.T, d *Deliverer) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for d.Stats().Pending > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Deliverer did not drain")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(2 * deliveryInterval)
+}
+
+// TestDeliverer_Frames tests that bursts are coalesced and single messages keep their event
+func TestDeliverer_Frames(t *testing.T) {
+	recorder := &eventRecorder{}
+	d := NewDeliverer(recorder.emit)
+
+	d.Push("newData", "first")
+	waitDelivered(t, d)
+	for i := 0; i < 30; i++ {
+		d.Push("newData", i)
+	}
+	d.Push("logLine", LogEntry{Line: "x"})
+	waitDelivered(t, d)
+
+	events := recorder.snapshot()
+	if len(events) == 0 || events[0].name != "newData" || events[0].data != "first" {
+		t.Fatalf("A lone message should be emitted as is, got %+v", events)
+	}
+
+	batched := 0
+	for _, ev := range events[1:] {
+		switch ev.name {
+		case "newDataBatch":
+			batched += len(ev.data.([]interface{}))
+		case "newData":
+			batched++
+		case "logLine":
+		default:
+			t.Errorf("Unexpected event %s", ev.name)
+		}
+	}
+	if batched != 30 {
+		t.Errorf("Expected 30 dumps delivered, got %d", batched)
+	}
+
+	stats := d.Stats()
+	if stats.Pushed != 32 || stats.Delivered != 32 || stats.Dropped != 0 || stats.Coalesced == 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestDeliverer_Order tests that repeats never reach the UI before the dump they update
+func TestDeliverer_Order(t *testing.T) {
+	recorder := &eventRecorder{}
+	d := NewDeliverer(recorder.emit)
+	d.Configure(DeliveryDropOldest, 40) // 2 messages per frame
+
+	// Hold the deliverer so every flush below sees the whole burst
+	d.mu.Lock()
+	d.lastFlush = time.Now().Add(time.Hour)
+	d.mu.Unlock()
+	for i := 0; i < 4; i++ {
+		d.Push("newData", i)
+		d.Push("dumpRepeated", i)
+	}
+	d.Push("logLine", LogEntry{Line: "x"})
+
+	for i := 0; i < 20; i++ {
+		d.flush()
+	}
+
+	var names []string
+	delivered := map[int]bool{}
+	for _, ev := range recorder.snapshot() {
+		names = append(names, ev.name)
+		items, ok := ev.data.([]interface{})
+		if !ok {
+			items = []interface{}{ev.data}
+		}
+		for _, item := range items {
+			n, ok := item.(int)
+			if !ok {
+				continue
+			}
+			switch ev.name {
+			case "newData", "newDataBatch":
+				delivered[n] = true
+			case "dumpRepeated", "dumpRepeatedBatch":
+				if !delivered[n] {
+					t.Errorf("Repeat of %d emitted before its dump: %v", n, names)
+				}
+			}
+		}
+	}
+	want := []string{"newDataBatch", "dumpRepeatedBatch", "newDataBatch", "dumpRepeatedBatch", "logLine"}
+	if len(names) != len(want) {
+		t.Fatalf("Expected frames %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Expected frames %v, got %v", want, names)
+		}
+	}
+}
+
+// TestDeliverer_RateLimit tests that frames never exceed the configured rate
+func TestDeliverer_RateLimit(t *testing.T) {
+	recorder := &eventRecorder{}
+	d := NewDeliverer(recorder.emit)
+	d.Configure(DeliveryDropOldest, 100) // 5 messages per 50ms frame
+
+	for i := 0; i < 20; i++ {
+		d.Push("newData", i)
+	}
+	waitDelivered(t, d)
+
+	for _, ev := range recorder.snapshot() {
+		if items, ok := ev.data.([]interface{}); ok && len(items) > 5 {
+			t.Errorf("Frame of %d exceeds the rate", len(items))
+		}
+	}
+	if frames := d.Stats().Frames; frames < 4 {
+		t.Errorf("Expected at least 4 frames, got %d", frames)
+	}
+}
+
+// TestDeliverer_Overflow tests the drop policies when the UI falls behind
+func TestDeliverer_Overflow(t *testing.T) {
+	testCases := []struct {
+		policy    string
+		first     int
+		summaries int
+	}{
+		{DeliveryDropOldest, 10, 0},
+		{DeliveryDropNewest, 0, 0},
+		{DeliverySummarize, 10, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			recorder := &eventRecorder{}
+			d := NewDeliverer(recorder.emit)
+			d.Configure(tc.policy, 5) // capacity 10, one message per frame
+
+			// Hold the deliverer so the burst overflows the buffer
+			d.mu.Lock()
+			d.lastFlush = time.Now().Add(time.Hour)
+			d.mu.Unlock()
+			for i := 0; i < 20; i++ {
+				d.Push("newData", i)
+			}
+			if stats := d.Stats(); stats.Dropped != 10 || stats.Pending != 10 {
+				t.Fatalf("Unexpected stats: %+v", stats)
+			}
+
+			d.flush()
+			events := recorder.snapshot()
+			if len(events) == 0 || events[0].data != tc.first {
+				t.Errorf("Expected %d delivered first, got %+v", tc.first, events)
+			}
+			summaries := 0
+			for _, ev := range events {
+				if ev.name == "eventsDropped" {
+					summaries++
+					if dropped := ev.data.(DroppedEvents); dropped.Count != 10 || dropped.Event != "newData" {
+						t.Errorf("Unexpected summary: %+v", dropped)
+					}
+				}
+			}
+			if summaries != tc.summaries {
+				t.Errorf("Expected %d summaries, got %d", tc.summaries, summaries)
+			}
+		})
+	}
+
+	if err := NewDeliverer(nil).Configure("block", 0); err == nil {
+		t.Error("Unknown policies should be rejected")
+	}
+}
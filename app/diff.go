@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Diff operations reported in a DiffChange.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+	DiffMoved   = "moved"
+)
+
+// DiffChange is one structural difference between two dumps. Path uses the
+// same dotted notation as query predicates, e.g. "context.items.2.price".
+type DiffChange struct {
+	Op        string      `json:"op"`
+	Path      string      `json:"path"`
+	From      interface{} `json:"from,omitempty"`
+	To        interface{} `json:"to,omitempty"`
+	FromIndex *int        `json:"from_index,omitempty"` // array moves only
+	ToIndex   *int        `json:"to_index,omitempty"`   // array moves only
+}
+
+// DumpDiff is the structural diff between the contexts of two dumps.
+type DumpDiff struct {
+	FromID  int64        `json:"from_id"`
+	ToID    int64        `json:"to_id"`
+	Label   string       `json:"label,omitempty"`
+	Changes []DiffChange `json:"changes"`
+	Added   int          `json:"added"`
+	Removed int          `json:"removed"`
+	Changed int          `json:"changed"`
+	Moved   int          `json:"moved"`
+}
+
+// DiffDumpMessages compares the contexts of from and to.
+func DiffDumpMessages(from, to *DumpMessage) (*DumpDiff, error) {
+	a, err := normalizeDiffValue(from.Context)
+	if err != nil {
+		return nil, fmt.Errorf("dump %d: %v", from.ID, err)
+	}
+	b, err := normalizeDiffValue(to.Context)
+	if err != nil {
+		return nil, fmt.Errorf("dump %d: %v", to.ID, err)
+	}
+
+	diff := &DumpDiff{FromID: from.ID, ToID: to.ID, Changes: DiffValues("context", a, b)}
+	if from.Label == to.Label {
+		diff.Label = to.Label
+	}
+	for _, c := range diff.Changes {
+		switch c.Op {
+		case DiffAdded:
+			diff.Added++
+		case DiffRemoved:
+			diff.Removed++
+		case DiffChanged:
+			diff.Changed++
+		case DiffMoved:
+			diff.Moved++
+		}
+	}
+	return diff, nil
+}
+
+// normalizeDiffValue round-trips v through JSON so Go values and decoded
+// payloads compare the same way.
+func normalizeDiffValue(v interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DiffValues returns the changes that turn a into b. Both must be decoded
+// JSON values (maps, slices, json.Number, string, bool or nil).
+func DiffValues(path string, a, b interface{}) []DiffChange {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			return diffObjects(path, av, bv)
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			return diffArrays(path, av, bv)
+		}
+	}
+	if diffKey(a) == diffKey(b) {
+		return nil
+	}
+	return []DiffChange{{Op: DiffChanged, Path: path, From: a, To: b}}
+}
+
+func diffObjects(path string, a, b map[string]interface{}) []DiffChange {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []DiffChange
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		p := joinDiffPath(path, k)
+		switch {
+		case !inB:
+			changes = append(changes, DiffChange{Op: DiffRemoved, Path: p, From: av})
+		case !inA:
+			changes = append(changes, DiffChange{Op: DiffAdded, Path: p, To: bv})
+		default:
+			changes = append(changes, DiffValues(p, av, bv)...)
+		}
+	}
+	return changes
+}
+
+// diffArrays matches elements that stayed in place first, then equal
+// elements that moved, and diffs what is left index by index.
+func diffArrays(path string, a, b []interface{}) []DiffChange {
+	keysA := make([]string, len(a))
+	for i, v := range a {
+		keysA[i] = diffKey(v)
+	}
+	keysB := make([]string, len(b))
+	for i, v := range b {
+		keysB[i] = diffKey(v)
+	}
+
+	matchedA := make([]bool, len(a))
+	matchedB := make([]bool, len(b))
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if keysA[i] == keysB[i] {
+			matchedA[i], matchedB[i] = true, true
+		}
+	}
+
+	var changes []DiffChange
+	for j := range b {
+		if matchedB[j] {
+			continue
+		}
+		for i := range a {
+			if !matchedA[i] && keysA[i] == keysB[j] {
+				matchedA[i], matchedB[j] = true, true
+				from, to := i, j
+				changes = append(changes, DiffChange{
+					Op: DiffMoved, Path: joinDiffPath(path, strconv.Itoa(j)), To: b[j], FromIndex: &from, ToIndex: &to,
+				})
+				break
+			}
+		}
+	}
+
+	for i := range a {
+		p := joinDiffPath(path, strconv.Itoa(i))
+		switch {
+		case matchedA[i]:
+		case i < len(b) && !matchedB[i]:
+			matchedB[i] = true
+			changes = append(changes, DiffValues(p, a[i], b[i])...)
+		default:
+			changes = append(changes, DiffChange{Op: DiffRemoved, Path: p, From: a[i]})
+		}
+	}
+	for j := range b {
+		if !matchedB[j] {
+			changes = append(changes, DiffChange{Op: DiffAdded, Path: joinDiffPath(path, strconv.Itoa(j)), To: b[j]})
+		}
+	}
+	return changes
+}
+
+// diffKey returns a canonical encoding of v; map keys are sorted by
+// encoding/json, so equal values produce equal keys.
+func diffKey(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(encoded)
+}
+
+func joinDiffPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
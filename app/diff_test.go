@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// decodeDiffJSON decodes s the way dump contexts are decoded
+func decodeDiffJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	out, err := normalizeDiffValue(v)
+	if err != nil {
+		t.Fatalf("normalizeDiffValue() failed: %v", err)
+	}
+	return out
+}
+
+// TestDiffValues tests added, removed, changed and moved entries
+func TestDiffValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected []string // "op path"
+	}{
+		{"equal", `{"a": 1, "b": [1, 2]}`, `{"b": [1, 2], "a": 1}`, nil},
+		{"added key", `{"a": 1}`, `{"a": 1, "b": 2}`, []string{"added context.b"}},
+		{"removed key", `{"a": 1, "b": 2}`, `{"a": 1}`, []string{"removed context.b"}},
+		{"changed scalar", `{"user": {"name": "ann"}}`, `{"user": {"name": "bob"}}`, []string{"changed context.user.name"}},
+		{"changed type", `{"a": 1}`, `{"a": "1"}`, []string{"changed context.a"}},
+		{"array append", `[1, 2]`, `[1, 2, 3]`, []string{"added context.2"}},
+		{"array remove", `[1, 2, 3]`, `[1, 2]`, []string{"removed context.2"}},
+		{"array element changed", `[{"id": 1, "qty": 1}]`, `[{"id": 1, "qty": 2}]`, []string{"changed context.0.qty"}},
+		{"array swap", `["a", "b"]`, `["b", "a"]`, []string{"moved context.0", "moved context.1"}},
+		{"array insert at front", `["a", "b"]`, `["x", "a", "b"]`, []string{"moved context.1", "moved context.2", "added context.0"}},
+		{"scalar root", `1`, `2`, []string{"changed context"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := DiffValues("context", decodeDiffJSON(t, tc.a), decodeDiffJSON(t, tc.b))
+			if len(changes) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %+v", tc.expected, changes)
+			}
+			for i, c := range changes {
+				if got := c.Op + " " + c.Path; got != tc.expected[i] {
+					t.Errorf("Change %d: expected %q, got %q", i, tc.expected[i], got)
+				}
+			}
+		})
+	}
+}
+
+// TestDiffValues_MoveIndexes tests that moves report both positions
+func TestDiffValues_MoveIndexes(t *testing.T) {
+	changes := DiffValues("items", decodeDiffJSON(t, `["a", "b", "c"]`), decodeDiffJSON(t, `["c", "b", "a"]`))
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 moves, got %+v", changes)
+	}
+	first := changes[0]
+	if first.Op != DiffMoved || *first.FromIndex != 2 || *first.ToIndex != 0 || first.To != "c" {
+		t.Errorf("Unexpected move: %+v", first)
+	}
+}
+
+// TestDiffDumpMessages tests diffing dump contexts and counting changes
+func TestDiffDumpMessages(t *testing.T) {
+	from := &DumpMessage{ID: 1, Label: "cart", Context: map[string]interface{}{
+		"total": 10, "items": []interface{}{"a"}, "coupon": "X",
+	}}
+	to := &DumpMessage{ID: 2, Label: "cart", Context: map[string]interface{}{
+		"total": 15, "items": []interface{}{"a", "b"}, "user": 7,
+	}}
+
+	diff, err := DiffDumpMessages(from, to)
+	if err != nil {
+		t.Fatalf("DiffDumpMessages() failed: %v", err)
+	}
+	if diff.FromID != 1 || diff.ToID != 2 || diff.Label != "cart" {
+		t.Errorf("Unexpected diff header: %+v", diff)
+	}
+	if diff.Added != 2 || diff.Removed != 1 || diff.Changed != 1 || diff.Moved != 0 {
+		t.Errorf("Unexpected counts: %+v", diff)
+	}
+
+	diff, _ = DiffDumpMessages(from, from)
+	if len(diff.Changes) != 0 {
+		t.Errorf("A dump should not differ from itself: %+v", diff.Changes)
+	}
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DumpSchemaVersion is the version of the canonical DumpMessage shape emitted
+// to the frontend and any other consumer. Bump it when fields change meaning.
+const DumpSchemaVersion = 1
+
+// DumpFrame is the source location a dump was produced from.
+type DumpFrame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+}
+
+// DumpMessage is the canonical, validated form of a payload posted to /data.
+// Both the legacy shape ({context, frame, color}) and the 2.2.0+ shape
+// ({context: {variables, file, line}, metadata: {color, trace, max_depth}})
+// are normalized into it by ParseDumpMessage.
+type DumpMessage struct {
+	Version    int                    `json:"version"`
+	ID         int64                  `json:"id"`
+	ReceivedAt time.Time              `json:"received_at"`
+	Label      string                 `json:"label,omitempty"`
+	Service    string                 `json:"service,omitempty"`    // sending service, e.g. vd($x)->service("auth")
+	RequestID  string                 `json:"request_id,omitempty"` // correlation ID of the client request
+	Once       bool                   `json:"once,omitempty"`       // shown once per session, as with ->once()
+	Color      string                 `json:"color,omitempty"`
+	Context    interface{}            `json:"context"`
+	Frame      *DumpFrame             `json:"frame,omitempty"`
+	Trace      interface{}            `json:"trace,omitempty"`
+	MaxDepth   int                    `json:"max_depth,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Git        *GitInfo               `json:"git,omitempty"`
+	Profile    string                 `json:"profile,omitempty"`   // profile whose listener received it
+	Truncated  bool                   `json:"truncated,omitempty"` // parts were replaced by payload limit markers
+}
+
+// PayloadError describes why a payload was rejected. Field is the dotted path
+// of the offending field, or empty when the payload as a whole is invalid.
+type PayloadError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"error"`
+}
+
+func (e *PayloadError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// dumpSeq hands out message IDs. It is seeded from the clock so IDs keep
+// increasing across restarts.
+var dumpSeq atomic.Int64
+
+func init() {
+	dumpSeq.Store(time.Now().UnixMicro())
+}
+
+// nextDumpID returns a new unique message ID.
+func nextDumpID() int64 {
+	return dumpSeq.Add(1)
+}
+
+// ParseDumpMessage decodes, validates and normalizes a raw /data payload
+// under the default payload limits. The returned error is always a
+// *PayloadError.
+func ParseDumpMessage(body []byte) (*DumpMessage, error) {
+	return ParseDumpMessageLimited(bytes.NewReader(body), defaultPayloadLimits())
+}
+
+// ParseDumpMessageLimited is ParseDumpMessage for a streamed body. Values
+// nested deeper than limits.MaxDepth, members past limits.MaxKeys and
+// anything after limits.MaxBytes are replaced with markers and the message
+// is flagged as Truncated instead of being rejected.
+func ParseDumpMessageLimited(r io.Reader, limits PayloadLimits) (*DumpMessage, error) {
+	obj, truncated, err := decodeLimitedObject(limitBody(r, limits.MaxBytes), limits)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := normalizeDumpMessage(obj)
+	if err != nil {
+		return nil, err
+	}
+	msg.Truncated = truncated
+	return msg, nil
+}
+
+// decodePayloadObject decodes body as a single JSON object, keeping numbers
+// as json.Number so large integers survive re-encoding.
+func decodePayloadObject(body []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, &PayloadError{Message: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if decoder.More() {
+		return nil, &PayloadError{Message: "unexpected data after JSON value"}
+	}
+
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, &PayloadError{Message: "payload must be a JSON object"}
+	}
+	return obj, nil
+}
+
+// normalizeDumpMessage builds a DumpMessage from an already decoded payload
+// object, which must carry a non-null context.
+func normalizeDumpMessage(obj map[string]interface{}) (*DumpMessage, error) {
+	if obj["context"] == nil {
+		return nil, &PayloadError{Field: "context", Message: "is required"}
+	}
+	return normalizeDumpFields(obj)
+}
+
+// normalizeDumpFields builds a DumpMessage from a decoded payload object
+// whose context may be absent, as for checkpoints sent without data.
+func normalizeDumpFields(obj map[string]interface{}) (*DumpMessage, error) {
+	msg := &DumpMessage{
+		Version:    DumpSchemaVersion,
+		ID:         nextDumpID(),
+		ReceivedAt: time.Now(),
+	}
+
+	ctxValue := obj["context"]
+	msg.Context = ctxValue
+
+	// 2.2.0+ nests the dumped variables and the call site inside context.
+	var ctxFrame *DumpFrame
+	if ctxObj, ok := ctxValue.(map[string]interface{}); ok {
+		if vars, ok := ctxObj["variables"]; ok {
+			msg.Context = vars
+			if file, present := ctxObj["file"]; present {
+				fileStr, ok := file.(string)
+				if !ok {
+					return nil, &PayloadError{Field: "context.file", Message: "must be a string"}
+				}
+				line, err := optionalInt(ctxObj, "line", "context.line")
+				if err != nil {
+					return nil, err
+				}
+				ctxFrame = &DumpFrame{File: fileStr, Line: line}
+			}
+		}
+	}
+
+	label, err := optionalString(obj, "label", "label")
+	if err != nil {
+		return nil, err
+	}
+	msg.Label = label
+
+	var frameObj map[string]interface{}
+	if v, ok := obj["frame"]; ok && v != nil {
+		frameObj, ok = v.(map[string]interface{})
+		if !ok {
+			return nil, &PayloadError{Field: "frame", Message: "must be an object"}
+		}
+		file, err := optionalString(frameObj, "file", "frame.file")
+		if err != nil {
+			return nil, err
+		}
+		line, err := optionalInt(frameObj, "line", "frame.line")
+		if err != nil {
+			return nil, err
+		}
+		function, err := optionalString(frameObj, "function", "frame.function")
+		if err != nil {
+			return nil, err
+		}
+		if function == "" {
+			if function, err = optionalString(frameObj, "caller", "frame.caller"); err != nil {
+				return nil, err
+			}
+		}
+		msg.Frame = &DumpFrame{File: file, Line: line, Function: function}
+	}
+	if msg.Frame == nil && ctxFrame != nil {
+		msg.Frame = ctxFrame
+	}
+
+	var metadata map[string]interface{}
+	if v, ok := obj["metadata"]; ok && v != nil {
+		metadata, ok = v.(map[string]interface{})
+		if !ok {
+			return nil, &PayloadError{Field: "metadata", Message: "must be an object"}
+		}
+		msg.Metadata = metadata
+	}
+
+	// Color: metadata.color wins over the legacy top-level color.
+	if metadata != nil {
+		if msg.Color, err = optionalString(metadata, "color", "metadata.color"); err != nil {
+			return nil, err
+		}
+	}
+	if msg.Color == "" {
+		if msg.Color, err = optionalString(obj, "color", "color"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Service: metadata.service wins over the top-level service.
+	if metadata != nil {
+		if msg.Service, err = optionalString(metadata, "service", "metadata.service"); err != nil {
+			return nil, err
+		}
+	}
+	if msg.Service == "" {
+		if msg.Service, err = optionalString(obj, "service", "service"); err != nil {
+			return nil, err
+		}
+	}
+	msg.Service = normalizeService(msg.Service)
+
+	// Request ID: metadata.request_id, then request_id, then trace_id.
+	if metadata != nil {
+		if msg.RequestID, err = optionalString(metadata, "request_id", "metadata.request_id"); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range []string{"request_id", "trace_id"} {
+		if msg.RequestID != "" {
+			break
+		}
+		if msg.RequestID, err = optionalString(obj, key, key); err != nil {
+			return nil, err
+		}
+	}
+	msg.RequestID = normalizeRequestID(msg.RequestID)
+
+	// Once: set by metadata.once or the top-level once.
+	if metadata != nil {
+		if msg.Once, err = optionalBool(metadata, "once", "metadata.once"); err != nil {
+			return nil, err
+		}
+	}
+	if !msg.Once {
+		if msg.Once, err = optionalBool(obj, "once", "once"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Max depth: metadata.max_depth wins over the legacy top-level max_depth.
+	if metadata != nil {
+		if msg.MaxDepth, err = optionalInt(metadata, "max_depth", "metadata.max_depth"); err != nil {
+			return nil, err
+		}
+	}
+	if msg.MaxDepth == 0 {
+		if msg.MaxDepth, err = optionalInt(obj, "max_depth", "max_depth"); err != nil {
+			return nil, err
+		}
+	}
+	if msg.MaxDepth < 0 {
+		return nil, &PayloadError{Field: "max_depth", Message: "must not be negative"}
+	}
+
+	// Trace may come from several places depending on the client version.
+	switch {
+	case metadata != nil && isPresent(metadata["trace"]):
+		msg.Trace = metadata["trace"]
+	case isPresent(obj["trace"]):
+		msg.Trace = obj["trace"]
+	case frameObj != nil && isPresent(frameObj["trace"]):
+		msg.Trace = frameObj["trace"]
+	case isPresent(obj["stack"]):
+		msg.Trace = obj["stack"]
+	case isPresent(obj["backtrace"]):
+		msg.Trace = obj["backtrace"]
+	}
+
+	// Metadata keeps only what was not promoted to a field of its own, so
+	// the trace and the other promoted values are not sent twice.
+	for _, key := range promotedMetadataKeys {
+		delete(metadata, key)
+	}
+	if len(metadata) == 0 {
+		msg.Metadata = nil
+	}
+
+	return msg, nil
+}
+
+// promotedMetadataKeys are the metadata keys normalizeDumpFields moves to
+// fields of DumpMessage.
+var promotedMetadataKeys = []string{"color", "service", "request_id", "once", "max_depth", "trace"}
+
+// optionalString returns obj[key] as a string, or "" if it is absent or null.
+func optionalString(obj map[string]interface{}, key, field string) (string, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", &PayloadError{Field: field, Message: "must be a string"}
+	}
+	return s, nil
+}
+
+// optionalInt returns obj[key] as an int, or 0 if it is absent or null.
+// Numeric strings are accepted since older clients sent line numbers as text;
+// numbers with a fractional part are rejected however they are written.
+func optionalInt(obj map[string]interface{}, key, field string) (int, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	var n json.Number
+	switch value := v.(type) {
+	case json.Number:
+		n = value
+	case float64:
+		n = json.Number(strconv.FormatFloat(value, 'f', -1, 64))
+	case string:
+		if value == "" {
+			return 0, nil
+		}
+		n = json.Number(value)
+	default:
+		return 0, &PayloadError{Field: field, Message: "must be an integer"}
+	}
+	if i, err := n.Int64(); err == nil {
+		return int(i), nil
+	}
+	// 12.0 or 1e3 still name a whole number, as long as the float is exact
+	f, err := n.Float64()
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+		return 0, &PayloadError{Field: field, Message: "must be an integer"}
+	}
+	return int(f), nil
+}
+
+// isPresent reports whether a decoded JSON value carries information.
+func isPresent(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case string:
+		return value != ""
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	}
+	return true
+}
+
+// optionalBool returns obj[key] as a bool, or false if it is absent or null.
+func optionalBool(obj map[string]interface{}, key, field string) (bool, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, &PayloadError{Field: field, Message: "must be a boolean"}
+	}
+	return b, nil
+}
+
+// optionalUnixSeconds returns obj[key], a Unix timestamp in seconds such as
+// PHP's microtime(true), as a time, or the zero time if it is absent or null.
+func optionalUnixSeconds(obj map[string]interface{}, key, field string) (time.Time, error) {
+	v, ok := obj[key]
+	if !ok || v == nil {
+		return time.Time{}, nil
+	}
+	secs, ok := toFloat(v)
+	if !ok || secs <= 0 {
+		return time.Time{}, &PayloadError{Field: field, Message: "must be a positive Unix timestamp in seconds"}
+	}
+	whole := math.Floor(secs)
+	return time.Unix(int64(whole), int64(math.Round((secs-whole)*1e9))), nil
+}
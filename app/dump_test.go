@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestParseDumpMessage_Legacy tests normalizing the pre-2.2.0 payload shape
+func TestParseDumpMessage_Legacy(t *testing.T) {
+	body := `{
+		"context": {"user": {"id": 42}},
+		"frame": {"file": "/app/index.php", "line": 12, "function": "handle"},
+		"label": "user",
+		"color": "red",
+		"stack": ["a", "b"]
+	}`
+
+	msg, err := ParseDumpMessage([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseDumpMessage() failed: %v", err)
+	}
+
+	if msg.Version != DumpSchemaVersion {
+		t.Errorf("Expected version %d, got %d", DumpSchemaVersion, msg.Version)
+	}
+	if msg.ID == 0 {
+		t.Error("Expected a non-zero ID")
+	}
+	if msg.Label != "user" {
+		t.Errorf("Expected label 'user', got '%s'", msg.Label)
+	}
+	if msg.Color != "red" {
+		t.Errorf("Expected color 'red', got '%s'", msg.Color)
+	}
+	if msg.Frame == nil || msg.Frame.File != "/app/index.php" || msg.Frame.Line != 12 || msg.Frame.Function != "handle" {
+		t.Errorf("Unexpected frame: %+v", msg.Frame)
+	}
+	if msg.Trace == nil {
+		t.Error("Expected trace to be taken from stack")
+	}
+	if _, ok := msg.Context.(map[string]interface{})["user"]; !ok {
+		t.Errorf("Expected context to be kept as-is, got %v", msg.Context)
+	}
+}
+
+// TestParseDumpMessage_V220 tests normalizing the 2.2.0+ payload shape
+func TestParseDumpMessage_V220(t *testing.T) {
+	body := `{
+		"context": {"variables": {"order": 1}, "file": "/app/Order.php", "line": "30"},
+		"frame": null,
+		"metadata": {"color": "success", "trace": [{"file": "x"}], "max_depth": 3, "includeTrace": true},
+		"color": "ignored"
+	}`
+
+	msg, err := ParseDumpMessage([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseDumpMessage() failed: %v", err)
+	}
+
+	if msg.Color != "success" {
+		t.Errorf("Expected metadata color to win, got '%s'", msg.Color)
+	}
+	if msg.MaxDepth != 3 {
+		t.Errorf("Expected max_depth 3, got %d", msg.MaxDepth)
+	}
+	if msg.Frame == nil || msg.Frame.File != "/app/Order.php" || msg.Frame.Line != 30 {
+		t.Errorf("Expected frame from context, got %+v", msg.Frame)
+	}
+	if msg.Trace == nil {
+		t.Error("Expected trace from metadata")
+	}
+	if len(msg.Metadata) != 1 || msg.Metadata["includeTrace"] != true {
+		t.Errorf("Expected only the keys not promoted in metadata, got %v", msg.Metadata)
+	}
+	ctx, ok := msg.Context.(map[string]interface{})
+	if !ok || ctx["order"] == nil {
+		t.Errorf("Expected context to be the variables, got %v", msg.Context)
+	}
+
+	// Canonical output must round-trip as JSON
+	if _, err := json.Marshal(msg); err != nil {
+		t.Errorf("json.Marshal() failed: %v", err)
+	}
+}
+
+// TestParseDumpMessage_Invalid tests that rejected payloads name the offending field
+func TestParseDumpMessage_Invalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{name: "Not JSON", body: `{`, field: ""},
+		{name: "Not an object", body: `[1, 2]`, field: ""},
+		{name: "Trailing data", body: `{"context": 1} {}`, field: ""},
+		{name: "Missing context", body: `{"label": "x"}`, field: "context"},
+		{name: "Null context", body: `{"context": null, "label": "x"}`, field: "context"},
+		{name: "Frame line fractional", body: `{"context": 1, "frame": {"line": 12.5}}`, field: "frame.line"},
+		{name: "Context line fractional", body: `{"context": {"variables": 1, "file": "/a.php", "line": "3.5"}}`, field: "context.line"},
+		{name: "Label not string", body: `{"context": 1, "label": 5}`, field: "label"},
+		{name: "Frame not object", body: `{"context": 1, "frame": "x"}`, field: "frame"},
+		{name: "Frame line not int", body: `{"context": 1, "frame": {"line": "abc"}}`, field: "frame.line"},
+		{name: "Metadata not object", body: `{"context": 1, "metadata": []}`, field: "metadata"},
+		{name: "Color not string", body: `{"context": 1, "metadata": {"color": 1}}`, field: "metadata.color"},
+		{name: "Negative depth", body: `{"context": 1, "max_depth": -1}`, field: "max_depth"},
+		{name: "Context file not string", body: `{"context": {"variables": 1, "file": 3}}`, field: "context.file"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseDumpMessage([]byte(tc.body))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			var payloadErr *PayloadError
+			if !errors.As(err, &payloadErr) {
+				t.Fatalf("Expected *PayloadError, got %T", err)
+			}
+			if payloadErr.Field != tc.field {
+				t.Errorf("Expected field '%s', got '%s'", tc.field, payloadErr.Field)
+			}
+		})
+	}
+}
+
+// TestOptionalInt tests that whole numbers are accepted however they are written
+func TestOptionalInt(t *testing.T) {
+	testCases := []struct {
+		value interface{}
+		want  int
+		ok    bool
+	}{
+		{json.Number("12"), 12, true},
+		{json.Number("12.0"), 12, true},
+		{json.Number("1e3"), 1000, true},
+		{json.Number("12.5"), 0, false},
+		{float64(30), 30, true},
+		{float64(30.25), 0, false},
+		{"42", 42, true},
+		{"4.2", 0, false},
+		{"", 0, true},
+		{nil, 0, true},
+		{true, 0, false},
+	}
+	for _, tc := range testCases {
+		got, err := optionalInt(map[string]interface{}{"line": tc.value}, "line", "frame.line")
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("optionalInt(%#v) = %d, %v; want %d, ok=%v", tc.value, got, err, tc.want, tc.ok)
+		}
+	}
+}
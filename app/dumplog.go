@@ -0,0 +1,62 @@
+package main
+
+import "sync"
+
+// defaultDumpRetention matches the number of dumps the frontend keeps on screen.
+const defaultDumpRetention = 1000
+
+// DumpLog retains the most recently accepted dumps in memory so they can be
+// queried from Go without re-parsing the frontend's copy.
+type DumpLog struct {
+	mu       sync.RWMutex
+	messages []*DumpMessage // oldest first
+	limit    int
+}
+
+// NewDumpLog creates a DumpLog keeping at most limit messages.
+func NewDumpLog(limit int) *DumpLog {
+	if limit <= 0 {
+		limit = defaultDumpRetention
+	}
+	return &DumpLog{limit: limit}
+}
+
+// Add appends msg, evicting the oldest message once the limit is reached.
+func (l *DumpLog) Add(msg *DumpMessage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.messages) >= l.limit {
+		// Drop the oldest entry without letting the backing array grow forever.
+		copy(l.messages, l.messages[1:])
+		l.messages = l.messages[:len(l.messages)-1]
+	}
+	l.messages = append(l.messages, msg)
+}
+
+// Get returns the retained message with id, or nil.
+func (l *DumpLog) Get(id int64) *DumpMessage {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for i := len(l.messages) - 1; i >= 0; i-- {
+		if l.messages[i].ID == id {
+			return l.messages[i]
+		}
+	}
+	return nil
+}
+
+// Snapshot returns a copy of the retained messages, oldest first.
+func (l *DumpLog) Snapshot() []*DumpMessage {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]*DumpMessage, len(l.messages))
+	copy(out, l.messages)
+	return out
+}
+
+// Len returns the number of retained messages.
+func (l *DumpLog) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.messages)
+}
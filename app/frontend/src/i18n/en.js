@@ -45,6 +45,7 @@ export default {
     up_to_date: "Your application is up to date",
     latest_version: "You have the latest version",
     copied_to_clipboard: "Copied to clipboard",
+    events_dropped: "events dropped to keep the view responsive",
     // Profiles
     profile: "Profile",
     profiles: "Profiles",
@@ -47,6 +47,7 @@ export default {
     up_to_date: "Tu aplicación está actualizada",
     latest_version: "Tienes la versión más reciente",
     copied_to_clipboard: "Copiado al portapapeles",
+    events_dropped: "eventos descartados para mantener la vista fluida",
     // Perfiles
     profile: "Perfil",
     profiles: "Perfiles",
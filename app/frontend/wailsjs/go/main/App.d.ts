@@ -2,56 +2,152 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AbortCheckpoint(arg1:string):Promise<void>;
+
 export function AddLogFolder(arg1:string,arg2:string,arg3:Array<string>,arg4:Array<string>,arg5:string):Promise<void>;
 
 export function CheckForUpdates():Promise<main.UpdateInfo>;
 
+export function CheckServerHealth():Promise<boolean>;
+
+export function ClearHistory():Promise<void>;
+
+export function ClearRequests():Promise<void>;
+
 export function CreateProfile(arg1:string,arg2:string,arg3:number,arg4:string,arg5:string,arg6:boolean):Promise<void>;
 
+export function DeleteHistoryEntry(arg1:number):Promise<void>;
+
 export function DeleteProfile(arg1:string):Promise<void>;
 
+export function DiffDumps(arg1:number,arg2:number):Promise<main.DumpDiff>;
+
 export function DownloadAndInstallUpdate(arg1:string):Promise<void>;
 
+export function ExportCACertificate():Promise<string>;
+
 export function GetActiveProfileName():Promise<string>;
 
+export function GetAlertRules():Promise<Array<main.AlertRule>>;
+
+export function GetAutoDiff():Promise<boolean>;
+
+export function GetBenchmarks():Promise<Array<main.BenchmarkRecord>>;
+
+export function GetCACertificate():Promise<string>;
+
 export function GetConfig():Promise<main.Profile>;
 
 export function GetCurrentVersion():Promise<string>;
 
+export function GetDedupe():Promise<Record<string, any>>;
+
+export function GetDeliveryStats():Promise<main.DeliveryStats>;
+
+export function GetGitContext():Promise<boolean>;
+
+export function GetHistory(arg1:number,arg2:number):Promise<main.HistoryPage>;
+
+export function GetLabelHistory(arg1:string):Promise<Array<main.DumpMessage>>;
+
 export function GetLogFolders():Promise<Array<main.LogFolder>>;
 
 export function GetLogWatcherStatus():Promise<Record<string, any>>;
 
+export function GetPinnedLabels():Promise<Array<main.LabelSummary>>;
+
+export function GetRejectionStats():Promise<main.RejectionStats>;
+
+export function GetRequestDumps(arg1:string):Promise<Array<main.DumpMessage>>;
+
+export function GetRunningProfiles():Promise<Array<main.ProfileStatus>>;
+
+export function GetServiceFilter():Promise<Array<string>>;
+
+export function GetServices():Promise<Array<main.ServiceSummary>>;
+
+export function GetShareSession():Promise<main.ShareSession>;
+
 export function GetVisibleCount():Promise<number>;
 
 export function GetWindowPosition():Promise<main.WindowPosition>;
 
+export function ListCheckpoints():Promise<Array<main.Checkpoint>>;
+
+export function ListLabels():Promise<Array<main.LabelSummary>>;
+
 export function ListProfiles():Promise<Array<main.Profile>>;
 
+export function ListRequests():Promise<Array<main.RequestRecord>>;
+
+export function MuteService(arg1:string):Promise<void>;
+
 export function OpenInEditor(arg1:string,arg2:number):Promise<void>;
 
+export function PinLabel(arg1:string):Promise<void>;
+
+export function QueryDumps(arg1:main.DumpQuery):Promise<main.DumpQueryResult>;
+
 export function RemoveLogFolder(arg1:string,arg2:string):Promise<void>;
 
+export function ResetBenchmarks():Promise<void>;
+
+export function ResetDedupe():Promise<void>;
+
 export function RestartHTTPServer():Promise<void>;
 
 export function RestartLogWatcher():Promise<void>;
 
+export function ResumeCheckpoint(arg1:string):Promise<void>;
+
+export function SaveAlertRules(arg1:Array<main.AlertRule>):Promise<void>;
+
 export function SaveFrontendConfig(arg1:Record<string, any>):Promise<void>;
 
 export function SaveWindowPosition():Promise<void>;
 
+export function SearchHistory(arg1:string,arg2:number,arg3:number):Promise<main.HistoryPage>;
+
 export function SelectFolder():Promise<string>;
 
+export function SetAutoDiff(arg1:boolean):Promise<void>;
+
+export function SetDedupe(arg1:string,arg2:number):Promise<void>;
+
+export function SetGitContext(arg1:boolean):Promise<void>;
+
+export function SetHistoryEnabled(arg1:boolean):Promise<void>;
+
+export function SetLogFolderEntryStart(arg1:string,arg2:string,arg3:string,arg4:number):Promise<void>;
+
+export function SetLogFolderFields(arg1:string,arg2:string,arg3:main.LogFieldMapping):Promise<void>;
+
+export function SetLogFolderParser(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetServiceFilter(arg1:Array<string>):Promise<void>;
+
 export function StartLogWatcher():Promise<void>;
 
+export function StartProfile(arg1:string):Promise<void>;
+
+export function StartShareSession():Promise<main.ShareSession>;
+
 export function StopLogWatcher():Promise<void>;
 
+export function StopProfile(arg1:string):Promise<void>;
+
+export function StopShareSession():Promise<void>;
+
 export function SwitchProfile(arg1:string):Promise<void>;
 
 export function TestUpdateCheck():Promise<main.UpdateInfo>;
 
 export function ToggleLogFolder(arg1:string,arg2:string,arg3:boolean):Promise<void>;
 
+export function UnmuteService(arg1:string):Promise<void>;
+
+export function UnpinLabel(arg1:string):Promise<void>;
+
 export function UpdateLogFolder(arg1:string,arg2:string,arg3:Array<string>,arg4:Array<string>,arg5:string):Promise<void>;
 
 export function UpdateProfile(arg1:string,arg2:string,arg3:number,arg4:string,arg5:string,arg6:boolean):Promise<void>;
@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AbortCheckpoint(arg1) {
+  return window['go']['main']['App']['AbortCheckpoint'](arg1);
+}
+
 export function AddLogFolder(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['AddLogFolder'](arg1, arg2, arg3, arg4, arg5);
 }
@@ -10,22 +14,62 @@ export function CheckForUpdates() {
   return window['go']['main']['App']['CheckForUpdates']();
 }
 
+export function CheckServerHealth() {
+  return window['go']['main']['App']['CheckServerHealth']();
+}
+
+export function ClearHistory() {
+  return window['go']['main']['App']['ClearHistory']();
+}
+
+export function ClearRequests() {
+  return window['go']['main']['App']['ClearRequests']();
+}
+
 export function CreateProfile(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['main']['App']['CreateProfile'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
+export function DeleteHistoryEntry(arg1) {
+  return window['go']['main']['App']['DeleteHistoryEntry'](arg1);
+}
+
 export function DeleteProfile(arg1) {
   return window['go']['main']['App']['DeleteProfile'](arg1);
 }
 
+export function DiffDumps(arg1, arg2) {
+  return window['go']['main']['App']['DiffDumps'](arg1, arg2);
+}
+
 export function DownloadAndInstallUpdate(arg1) {
   return window['go']['main']['App']['DownloadAndInstallUpdate'](arg1);
 }
 
+export function ExportCACertificate() {
+  return window['go']['main']['App']['ExportCACertificate']();
+}
+
 export function GetActiveProfileName() {
   return window['go']['main']['App']['GetActiveProfileName']();
 }
 
+export function GetAlertRules() {
+  return window['go']['main']['App']['GetAlertRules']();
+}
+
+export function GetAutoDiff() {
+  return window['go']['main']['App']['GetAutoDiff']();
+}
+
+export function GetBenchmarks() {
+  return window['go']['main']['App']['GetBenchmarks']();
+}
+
+export function GetCACertificate() {
+  return window['go']['main']['App']['GetCACertificate']();
+}
+
 export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
@@ -34,6 +78,26 @@ export function GetCurrentVersion() {
   return window['go']['main']['App']['GetCurrentVersion']();
 }
 
+export function GetDedupe() {
+  return window['go']['main']['App']['GetDedupe']();
+}
+
+export function GetDeliveryStats() {
+  return window['go']['main']['App']['GetDeliveryStats']();
+}
+
+export function GetGitContext() {
+  return window['go']['main']['App']['GetGitContext']();
+}
+
+export function GetHistory(arg1, arg2) {
+  return window['go']['main']['App']['GetHistory'](arg1, arg2);
+}
+
+export function GetLabelHistory(arg1) {
+  return window['go']['main']['App']['GetLabelHistory'](arg1);
+}
+
 export function GetLogFolders() {
   return window['go']['main']['App']['GetLogFolders']();
 }
@@ -42,6 +106,34 @@ export function GetLogWatcherStatus() {
   return window['go']['main']['App']['GetLogWatcherStatus']();
 }
 
+export function GetPinnedLabels() {
+  return window['go']['main']['App']['GetPinnedLabels']();
+}
+
+export function GetRejectionStats() {
+  return window['go']['main']['App']['GetRejectionStats']();
+}
+
+export function GetRequestDumps(arg1) {
+  return window['go']['main']['App']['GetRequestDumps'](arg1);
+}
+
+export function GetRunningProfiles() {
+  return window['go']['main']['App']['GetRunningProfiles']();
+}
+
+export function GetServiceFilter() {
+  return window['go']['main']['App']['GetServiceFilter']();
+}
+
+export function GetServices() {
+  return window['go']['main']['App']['GetServices']();
+}
+
+export function GetShareSession() {
+  return window['go']['main']['App']['GetShareSession']();
+}
+
 export function GetVisibleCount() {
   return window['go']['main']['App']['GetVisibleCount']();
 }
@@ -50,18 +142,50 @@ export function GetWindowPosition() {
   return window['go']['main']['App']['GetWindowPosition']();
 }
 
+export function ListCheckpoints() {
+  return window['go']['main']['App']['ListCheckpoints']();
+}
+
+export function ListLabels() {
+  return window['go']['main']['App']['ListLabels']();
+}
+
 export function ListProfiles() {
   return window['go']['main']['App']['ListProfiles']();
 }
 
+export function ListRequests() {
+  return window['go']['main']['App']['ListRequests']();
+}
+
+export function MuteService(arg1) {
+  return window['go']['main']['App']['MuteService'](arg1);
+}
+
 export function OpenInEditor(arg1, arg2) {
   return window['go']['main']['App']['OpenInEditor'](arg1, arg2);
 }
 
+export function PinLabel(arg1) {
+  return window['go']['main']['App']['PinLabel'](arg1);
+}
+
+export function QueryDumps(arg1) {
+  return window['go']['main']['App']['QueryDumps'](arg1);
+}
+
 export function RemoveLogFolder(arg1, arg2) {
   return window['go']['main']['App']['RemoveLogFolder'](arg1, arg2);
 }
 
+export function ResetBenchmarks() {
+  return window['go']['main']['App']['ResetBenchmarks']();
+}
+
+export function ResetDedupe() {
+  return window['go']['main']['App']['ResetDedupe']();
+}
+
 export function RestartHTTPServer() {
   return window['go']['main']['App']['RestartHTTPServer']();
 }
@@ -70,6 +194,14 @@ export function RestartLogWatcher() {
   return window['go']['main']['App']['RestartLogWatcher']();
 }
 
+export function ResumeCheckpoint(arg1) {
+  return window['go']['main']['App']['ResumeCheckpoint'](arg1);
+}
+
+export function SaveAlertRules(arg1) {
+  return window['go']['main']['App']['SaveAlertRules'](arg1);
+}
+
 export function SaveFrontendConfig(arg1) {
   return window['go']['main']['App']['SaveFrontendConfig'](arg1);
 }
@@ -78,18 +210,70 @@ export function SaveWindowPosition() {
   return window['go']['main']['App']['SaveWindowPosition']();
 }
 
+export function SearchHistory(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SearchHistory'](arg1, arg2, arg3);
+}
+
 export function SelectFolder() {
   return window['go']['main']['App']['SelectFolder']();
 }
 
+export function SetAutoDiff(arg1) {
+  return window['go']['main']['App']['SetAutoDiff'](arg1);
+}
+
+export function SetDedupe(arg1, arg2) {
+  return window['go']['main']['App']['SetDedupe'](arg1, arg2);
+}
+
+export function SetGitContext(arg1) {
+  return window['go']['main']['App']['SetGitContext'](arg1);
+}
+
+export function SetHistoryEnabled(arg1) {
+  return window['go']['main']['App']['SetHistoryEnabled'](arg1);
+}
+
+export function SetLogFolderEntryStart(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetLogFolderEntryStart'](arg1, arg2, arg3, arg4);
+}
+
+export function SetLogFolderFields(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetLogFolderFields'](arg1, arg2, arg3);
+}
+
+export function SetLogFolderParser(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetLogFolderParser'](arg1, arg2, arg3);
+}
+
+export function SetServiceFilter(arg1) {
+  return window['go']['main']['App']['SetServiceFilter'](arg1);
+}
+
 export function StartLogWatcher() {
   return window['go']['main']['App']['StartLogWatcher']();
 }
 
+export function StartProfile(arg1) {
+  return window['go']['main']['App']['StartProfile'](arg1);
+}
+
+export function StartShareSession() {
+  return window['go']['main']['App']['StartShareSession']();
+}
+
 export function StopLogWatcher() {
   return window['go']['main']['App']['StopLogWatcher']();
 }
 
+export function StopProfile(arg1) {
+  return window['go']['main']['App']['StopProfile'](arg1);
+}
+
+export function StopShareSession() {
+  return window['go']['main']['App']['StopShareSession']();
+}
+
 export function SwitchProfile(arg1) {
   return window['go']['main']['App']['SwitchProfile'](arg1);
 }
@@ -102,6 +286,14 @@ export function ToggleLogFolder(arg1, arg2, arg3) {
   return window['go']['main']['App']['ToggleLogFolder'](arg1, arg2, arg3);
 }
 
+export function UnmuteService(arg1) {
+  return window['go']['main']['App']['UnmuteService'](arg1);
+}
+
+export function UnpinLabel(arg1) {
+  return window['go']['main']['App']['UnpinLabel'](arg1);
+}
+
 export function UpdateLogFolder(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['UpdateLogFolder'](arg1, arg2, arg3, arg4, arg5);
 }
@@ -1,11 +1,573 @@
 export namespace main {
 	
+	export class AlertRule {
+	    name: string;
+	    enabled: boolean;
+	    source?: string;
+	    label?: string;
+	    color?: string;
+	    contains?: string;
+	    where?: string;
+	    cooldown?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlertRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.source = source["source"];
+	        this.label = source["label"];
+	        this.color = source["color"];
+	        this.contains = source["contains"];
+	        this.where = source["where"];
+	        this.cooldown = source["cooldown"];
+	    }
+	}
+	export class BenchmarkRecord {
+	    name: string;
+	    count: number;
+	    running: number;
+	    total_ms: number;
+	    min_ms: number;
+	    max_ms: number;
+	    avg_ms: number;
+	    p95_ms: number;
+	    last_ms: number;
+	    last_lap_ms?: number;
+	    laps?: number;
+	    restarts?: number;
+	    abandoned?: number;
+	    last_memory_delta: number;
+	    max_memory_delta: number;
+	    avg_memory_delta: number;
+	    // Go type: time
+	    last_updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new BenchmarkRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.count = source["count"];
+	        this.running = source["running"];
+	        this.total_ms = source["total_ms"];
+	        this.min_ms = source["min_ms"];
+	        this.max_ms = source["max_ms"];
+	        this.avg_ms = source["avg_ms"];
+	        this.p95_ms = source["p95_ms"];
+	        this.last_ms = source["last_ms"];
+	        this.last_lap_ms = source["last_lap_ms"];
+	        this.laps = source["laps"];
+	        this.restarts = source["restarts"];
+	        this.abandoned = source["abandoned"];
+	        this.last_memory_delta = source["last_memory_delta"];
+	        this.max_memory_delta = source["max_memory_delta"];
+	        this.avg_memory_delta = source["avg_memory_delta"];
+	        this.last_updated_at = this.convertValues(source["last_updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GitInfo {
+	    repo: string;
+	    branch?: string;
+	    commit?: string;
+	    dirty: boolean;
+	    author?: string;
+	    author_email?: string;
+	    blame_commit?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GitInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repo = source["repo"];
+	        this.branch = source["branch"];
+	        this.commit = source["commit"];
+	        this.dirty = source["dirty"];
+	        this.author = source["author"];
+	        this.author_email = source["author_email"];
+	        this.blame_commit = source["blame_commit"];
+	    }
+	}
+	export class DumpFrame {
+	    file: string;
+	    line: number;
+	    function?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DumpFrame(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.function = source["function"];
+	    }
+	}
+	export class DumpMessage {
+	    version: number;
+	    id: number;
+	    // Go type: time
+	    received_at: any;
+	    label?: string;
+	    service?: string;
+	    request_id?: string;
+	    once?: boolean;
+	    color?: string;
+	    context: any;
+	    frame?: DumpFrame;
+	    trace?: any;
+	    max_depth?: number;
+	    metadata?: Record<string, any>;
+	    git?: GitInfo;
+	    profile?: string;
+	    truncated?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DumpMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.id = source["id"];
+	        this.received_at = this.convertValues(source["received_at"], null);
+	        this.label = source["label"];
+	        this.service = source["service"];
+	        this.request_id = source["request_id"];
+	        this.once = source["once"];
+	        this.color = source["color"];
+	        this.context = source["context"];
+	        this.frame = this.convertValues(source["frame"], DumpFrame);
+	        this.trace = source["trace"];
+	        this.max_depth = source["max_depth"];
+	        this.metadata = source["metadata"];
+	        this.git = this.convertValues(source["git"], GitInfo);
+	        this.profile = source["profile"];
+	        this.truncated = source["truncated"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Checkpoint {
+	    id: string;
+	    label?: string;
+	    dump?: DumpMessage;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    deadline: any;
+	    on_timeout: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Checkpoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.label = source["label"];
+	        this.dump = this.convertValues(source["dump"], DumpMessage);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.deadline = this.convertValues(source["deadline"], null);
+	        this.on_timeout = source["on_timeout"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeliveryStats {
+	    pushed: number;
+	    delivered: number;
+	    coalesced: number;
+	    dropped: number;
+	    frames: number;
+	    pending: number;
+	    policy: string;
+	    rate: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeliveryStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pushed = source["pushed"];
+	        this.delivered = source["delivered"];
+	        this.coalesced = source["coalesced"];
+	        this.dropped = source["dropped"];
+	        this.frames = source["frames"];
+	        this.pending = source["pending"];
+	        this.policy = source["policy"];
+	        this.rate = source["rate"];
+	    }
+	}
+	export class DiffChange {
+	    op: string;
+	    path: string;
+	    from?: any;
+	    to?: any;
+	    from_index?: number;
+	    to_index?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.op = source["op"];
+	        this.path = source["path"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.from_index = source["from_index"];
+	        this.to_index = source["to_index"];
+	    }
+	}
+	export class DumpDiff {
+	    from_id: number;
+	    to_id: number;
+	    label?: string;
+	    changes: DiffChange[];
+	    added: number;
+	    removed: number;
+	    changed: number;
+	    moved: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DumpDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from_id = source["from_id"];
+	        this.to_id = source["to_id"];
+	        this.label = source["label"];
+	        this.changes = this.convertValues(source["changes"], DiffChange);
+	        this.added = source["added"];
+	        this.removed = source["removed"];
+	        this.changed = source["changed"];
+	        this.moved = source["moved"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class DumpQuery {
+	    profile?: string;
+	    text?: string;
+	    label?: string;
+	    service?: string;
+	    request?: string;
+	    color?: string;
+	    file?: string;
+	    // Go type: time
+	    since?: any;
+	    // Go type: time
+	    until?: any;
+	    where?: string[];
+	    page?: number;
+	    page_size?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DumpQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.profile = source["profile"];
+	        this.text = source["text"];
+	        this.label = source["label"];
+	        this.service = source["service"];
+	        this.request = source["request"];
+	        this.color = source["color"];
+	        this.file = source["file"];
+	        this.since = this.convertValues(source["since"], null);
+	        this.until = this.convertValues(source["until"], null);
+	        this.where = source["where"];
+	        this.page = source["page"];
+	        this.page_size = source["page_size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DumpQueryResult {
+	    messages: DumpMessage[];
+	    total: number;
+	    page: number;
+	    page_size: number;
+	    source?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DumpQueryResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.messages = this.convertValues(source["messages"], DumpMessage);
+	        this.total = source["total"];
+	        this.page = source["page"];
+	        this.page_size = source["page_size"];
+	        this.source = source["source"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class HistoryRecord {
+	    id: number;
+	    // Go type: time
+	    received_at: any;
+	    profile: string;
+	    label?: string;
+	    color?: string;
+	    file?: string;
+	    line?: number;
+	    payload: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.received_at = this.convertValues(source["received_at"], null);
+	        this.profile = source["profile"];
+	        this.label = source["label"];
+	        this.color = source["color"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.payload = source["payload"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryPage {
+	    records: HistoryRecord[];
+	    total: number;
+	    page: number;
+	    page_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.records = this.convertValues(source["records"], HistoryRecord);
+	        this.total = source["total"];
+	        this.page = source["page"];
+	        this.page_size = source["page_size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LabelSummary {
+	    label: string;
+	    count: number;
+	    pinned: boolean;
+	    // Go type: time
+	    last_seen: any;
+	    latest?: DumpMessage;
+	
+	    static createFrom(source: any = {}) {
+	        return new LabelSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.label = source["label"];
+	        this.count = source["count"];
+	        this.pinned = source["pinned"];
+	        this.last_seen = this.convertValues(source["last_seen"], null);
+	        this.latest = this.convertValues(source["latest"], DumpMessage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LogFieldMapping {
+	    level?: string[];
+	    message?: string[];
+	    timestamp?: string[];
+	    context?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LogFieldMapping(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.level = source["level"];
+	        this.message = source["message"];
+	        this.timestamp = source["timestamp"];
+	        this.context = source["context"];
+	    }
+	}
 	export class LogFolder {
 	    path: string;
 	    extensions: string[];
 	    filters: string[];
 	    enabled: boolean;
 	    format?: string;
+	    fields?: LogFieldMapping;
+	    entry_start?: string;
+	    entry_flush_ms?: number;
+	    parser?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new LogFolder(source);
@@ -18,7 +580,29 @@ export namespace main {
 	        this.filters = source["filters"];
 	        this.enabled = source["enabled"];
 	        this.format = source["format"];
+	        this.fields = this.convertValues(source["fields"], LogFieldMapping);
+	        this.entry_start = source["entry_start"];
+	        this.entry_flush_ms = source["entry_flush_ms"];
+	        this.parser = source["parser"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Profile {
 	    name: string;
@@ -28,6 +612,29 @@ export namespace main {
 	    language?: string;
 	    show_types?: boolean;
 	    log_folders?: LogFolder[];
+	    history?: boolean;
+	    pinned_labels?: string[];
+	    muted_services?: string[];
+	    alert_rules?: AlertRule[];
+	    auto_diff?: boolean;
+	    git_context?: boolean;
+	    auth_token?: string;
+	    allowed_ips?: string[];
+	    cors_origins?: string[];
+	    tls?: boolean;
+	    tls_cert?: string;
+	    tls_key?: string;
+	    socket?: string;
+	    socket_mode?: string;
+	    socket_group?: string;
+	    disable_tcp?: boolean;
+	    max_body_mb?: number;
+	    max_json_depth?: number;
+	    max_json_keys?: number;
+	    delivery_policy?: string;
+	    delivery_rate?: number;
+	    dedupe?: string;
+	    dedupe_window?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new Profile(source);
@@ -42,6 +649,222 @@ export namespace main {
 	        this.language = source["language"];
 	        this.show_types = source["show_types"];
 	        this.log_folders = this.convertValues(source["log_folders"], LogFolder);
+	        this.history = source["history"];
+	        this.pinned_labels = source["pinned_labels"];
+	        this.muted_services = source["muted_services"];
+	        this.alert_rules = this.convertValues(source["alert_rules"], AlertRule);
+	        this.auto_diff = source["auto_diff"];
+	        this.git_context = source["git_context"];
+	        this.auth_token = source["auth_token"];
+	        this.allowed_ips = source["allowed_ips"];
+	        this.cors_origins = source["cors_origins"];
+	        this.tls = source["tls"];
+	        this.tls_cert = source["tls_cert"];
+	        this.tls_key = source["tls_key"];
+	        this.socket = source["socket"];
+	        this.socket_mode = source["socket_mode"];
+	        this.socket_group = source["socket_group"];
+	        this.disable_tcp = source["disable_tcp"];
+	        this.max_body_mb = source["max_body_mb"];
+	        this.max_json_depth = source["max_json_depth"];
+	        this.max_json_keys = source["max_json_keys"];
+	        this.delivery_policy = source["delivery_policy"];
+	        this.delivery_rate = source["delivery_rate"];
+	        this.dedupe = source["dedupe"];
+	        this.dedupe_window = source["dedupe_window"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileStatus {
+	    name: string;
+	    active: boolean;
+	    address?: string;
+	    socket?: string;
+	    tls: boolean;
+	    watching: boolean;
+	    // Go type: time
+	    started_at: any;
+	    messages: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.active = source["active"];
+	        this.address = source["address"];
+	        this.socket = source["socket"];
+	        this.tls = source["tls"];
+	        this.watching = source["watching"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.messages = source["messages"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RejectionStats {
+	    unauthorized: number;
+	    ip: number;
+	    origin: number;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RejectionStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.unauthorized = source["unauthorized"];
+	        this.ip = source["ip"];
+	        this.origin = source["origin"];
+	        this.total = source["total"];
+	    }
+	}
+	export class RequestRecord {
+	    id: string;
+	    profile?: string;
+	    method?: string;
+	    url?: string;
+	    status?: number;
+	    service?: string;
+	    // Go type: time
+	    started_at: any;
+	    ended: boolean;
+	    duration_ms: number;
+	    dump_count: number;
+	    severity?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RequestRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.profile = source["profile"];
+	        this.method = source["method"];
+	        this.url = source["url"];
+	        this.status = source["status"];
+	        this.service = source["service"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.ended = source["ended"];
+	        this.duration_ms = source["duration_ms"];
+	        this.dump_count = source["dump_count"];
+	        this.severity = source["severity"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ServiceSummary {
+	    service: string;
+	    count: number;
+	    muted: boolean;
+	    // Go type: time
+	    first_seen?: any;
+	    // Go type: time
+	    last_seen?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServiceSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.service = source["service"];
+	        this.count = source["count"];
+	        this.muted = source["muted"];
+	        this.first_seen = this.convertValues(source["first_seen"], null);
+	        this.last_seen = this.convertValues(source["last_seen"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ShareSession {
+	    token: string;
+	    url: string;
+	    ws_url: string;
+	    // Go type: time
+	    started_at: any;
+	    viewers: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShareSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.token = source["token"];
+	        this.url = source["url"];
+	        this.ws_url = source["ws_url"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.viewers = source["viewers"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
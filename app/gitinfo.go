@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// gitCacheTTL is how long branch, commit and dirty state are reused
+	// before the repository is read again.
+	gitCacheTTL = 5 * time.Second
+	// gitCommandTimeout bounds the git status and blame calls.
+	gitCommandTimeout = 2 * time.Second
+	// maxGitBlameCache bounds the number of cached blame lines.
+	maxGitBlameCache = 1000
+)
+
+// GitInfo is the git context of the file a dump was sent from.
+type GitInfo struct {
+	Repo        string `json:"repo"`
+	Branch      string `json:"branch,omitempty"` // empty when HEAD is detached
+	Commit      string `json:"commit,omitempty"`
+	Dirty       bool   `json:"dirty"`
+	Author      string `json:"author,omitempty"` // blame author of the dumped line
+	AuthorEmail string `json:"author_email,omitempty"`
+	BlameCommit string `json:"blame_commit,omitempty"`
+}
+
+type gitRepoState struct {
+	branch    string
+	commit    string
+	dirty     bool
+	fetchedAt time.Time
+}
+
+type gitBlame struct {
+	author    string
+	email     string
+	commit    string
+	fetchedAt time.Time
+}
+
+// GitResolver finds the repository of a local file and reads its state.
+// Branch and commit are read straight from .git; dirty state and blame use
+// the git executable when it is installed. Nothing touches the network.
+type GitResolver struct {
+	mu     sync.Mutex
+	roots  map[string]string // directory -> repository root, "" if none
+	repos  map[string]*gitRepoState
+	blames map[string]*gitBlame // "path:line" -> blame
+	gitBin string
+}
+
+// NewGitResolver creates a resolver with empty caches.
+func NewGitResolver() *GitResolver {
+	gitBin, _ := exec.LookPath("git")
+	return &GitResolver{
+		roots:  make(map[string]string),
+		repos:  make(map[string]*gitRepoState),
+		blames: make(map[string]*gitBlame),
+		gitBin: gitBin,
+	}
+}
+
+// Resolve returns the git context of file at line, or nil if the file does
+// not exist locally or is not inside a repository.
+func (g *GitResolver) Resolve(file string, line int) *GitInfo {
+	if file == "" {
+		return nil
+	}
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	root, gitDir := g.findRoot(filepath.Dir(path))
+	if root == "" {
+		return nil
+	}
+
+	state := g.repos[root]
+	if state == nil || time.Since(state.fetchedAt) > gitCacheTTL {
+		state = &gitRepoState{fetchedAt: time.Now()}
+		state.branch, state.commit, err = readGitHead(gitDir)
+		if err != nil {
+			return nil
+		}
+		state.dirty = g.isDirty(root)
+		g.repos[root] = state
+	}
+
+	info := &GitInfo{Repo: root, Branch: state.branch, Commit: state.commit, Dirty: state.dirty}
+	if line > 0 {
+		if blame := g.blame(root, path, line); blame != nil {
+			info.Author = blame.author
+			info.AuthorEmail = blame.email
+			info.BlameCommit = blame.commit
+		}
+	}
+	return info
+}
+
+// findRoot walks up from dir to the directory holding .git.
+// Callers must hold mu.
+func (g *GitResolver) findRoot(dir string) (root string, gitDir string) {
+	if root, ok := g.roots[dir]; ok {
+		if root == "" {
+			return "", ""
+		}
+		gitDir, _ := resolveGitDir(root)
+		return root, gitDir
+	}
+
+	root, gitDir = findGitRoot(dir)
+	g.roots[dir] = root
+	return root, gitDir
+}
+
+// findGitRoot walks up from dir until it finds a .git directory or file.
+func findGitRoot(dir string) (root string, gitDir string) {
+	for {
+		if gitDir, ok := resolveGitDir(dir); ok {
+			return dir, gitDir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// resolveGitDir returns the git directory of root. A .git file (worktrees
+// and submodules) points at the real directory with "gitdir: <path>".
+func resolveGitDir(root string) (string, bool) {
+	dotGit := filepath.Join(root, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return dotGit, true
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", false
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", false
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	return filepath.Clean(target), true
+}
+
+// readGitHead reads the current branch and commit from gitDir.
+func readGitHead(gitDir string) (branch string, commit string, err error) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", "", err
+	}
+	head := strings.TrimSpace(string(data))
+
+	ref, ok := strings.CutPrefix(head, "ref:")
+	if !ok {
+		// Detached HEAD holds the commit itself.
+		return "", head, nil
+	}
+	ref = strings.TrimSpace(ref)
+	branch = strings.TrimPrefix(ref, "refs/heads/")
+
+	// Linked worktrees keep their refs in the main repository.
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	for _, dir := range []string{gitDir, commonDir} {
+		if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			return branch, strings.TrimSpace(string(data)), nil
+		}
+	}
+	commit, err = readPackedRef(filepath.Join(commonDir, "packed-refs"), ref)
+	if err != nil {
+		// A branch without commits yet has no ref at all.
+		return branch, "", nil
+	}
+	return branch, commit, nil
+}
+
+// readPackedRef looks ref up in a packed-refs file.
+func readPackedRef(path, ref string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		sha, name, ok := strings.Cut(line, " ")
+		if ok && name == ref {
+			return sha, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("ref '%s' not found", ref)
+}
+
+// runGit runs git in root and returns its output.
+func (g *GitResolver) runGit(root string, args ...string) ([]byte, error) {
+	if g.gitBin == "" {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, g.gitBin, append([]string{"-C", root}, args...)...)
+	return cmd.Output()
+}
+
+// isDirty reports whether tracked files in root have uncommitted changes.
+func (g *GitResolver) isDirty(root string) bool {
+	out, err := g.runGit(root, "status", "--porcelain", "--untracked-files=no")
+	return err == nil && len(bytes.TrimSpace(out)) > 0
+}
+
+// blame returns the author of line in path. Callers must hold mu.
+func (g *GitResolver) blame(root, path string, line int) *gitBlame {
+	key := path + ":" + strconv.Itoa(line)
+	if b, ok := g.blames[key]; ok && time.Since(b.fetchedAt) <= gitCacheTTL {
+		return b
+	}
+
+	out, err := g.runGit(root, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "--", path)
+	if err != nil {
+		return nil
+	}
+	b := parseGitBlame(out)
+	if b == nil {
+		return nil
+	}
+	b.fetchedAt = time.Now()
+
+	if len(g.blames) >= maxGitBlameCache {
+		g.blames = make(map[string]*gitBlame)
+	}
+	g.blames[key] = b
+	return b
+}
+
+// parseGitBlame reads the header of `git blame --porcelain` output.
+func parseGitBlame(out []byte) *gitBlame {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if !scanner.Scan() {
+		return nil
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) == 0 {
+		return nil
+	}
+	b := &gitBlame{commit: fields[0]}
+	if strings.Trim(b.commit, "0") == "" {
+		// Lines not committed yet are blamed on the all-zero commit.
+		b.commit = ""
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			break // the line content ends the header
+		}
+		if v, ok := strings.CutPrefix(line, "author-mail "); ok {
+			b.email = strings.Trim(v, "<>")
+		} else if v, ok := strings.CutPrefix(line, "author "); ok {
+			b.author = v
+		}
+	}
+	return b
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeGitFile creates a file under dir, creating parent directories
+func writeGitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// TestReadGitHead tests branch and commit resolution from loose and packed refs
+func TestReadGitHead(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+
+	testCases := []struct {
+		name           string
+		files          map[string]string
+		expectedBranch string
+		expectedCommit string
+	}{
+		{
+			name:           "loose ref",
+			files:          map[string]string{"HEAD": "ref: refs/heads/main\n", "refs/heads/main": sha + "\n"},
+			expectedBranch: "main",
+			expectedCommit: sha,
+		},
+		{
+			name: "packed ref",
+			files: map[string]string{
+				"HEAD":        "ref: refs/heads/feature/login\n",
+				"packed-refs": "# pack-refs with: peeled fully-peeled sorted\n" + sha + " refs/heads/feature/login\n",
+			},
+			expectedBranch: "feature/login",
+			expectedCommit: sha,
+		},
+		{
+			name:           "detached",
+			files:          map[string]string{"HEAD": sha + "\n"},
+			expectedCommit: sha,
+		},
+		{
+			name:           "unborn branch",
+			files:          map[string]string{"HEAD": "ref: refs/heads/main\n"},
+			expectedBranch: "main",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gitDir := t.TempDir()
+			for name, content := range tc.files {
+				writeGitFile(t, gitDir, name, content)
+			}
+
+			branch, commit, err := readGitHead(gitDir)
+			if err != nil {
+				t.Fatalf("readGitHead() failed: %v", err)
+			}
+			if branch != tc.expectedBranch || commit != tc.expectedCommit {
+				t.Errorf("Expected %q@%q, got %q@%q", tc.expectedBranch, tc.expectedCommit, branch, commit)
+			}
+		})
+	}
+}
+
+// TestFindGitRoot tests walking up to .git, including .git files used by worktrees
+func TestFindGitRoot(t *testing.T) {
+	repo := t.TempDir()
+	writeGitFile(t, repo, ".git/HEAD", "ref: refs/heads/main\n")
+	writeGitFile(t, repo, "src/app/index.php", "<?php\n")
+
+	root, gitDir := findGitRoot(filepath.Join(repo, "src", "app"))
+	if root != repo || gitDir != filepath.Join(repo, ".git") {
+		t.Errorf("Unexpected root %q (git dir %q)", root, gitDir)
+	}
+
+	worktree := t.TempDir()
+	writeGitFile(t, worktree, ".git", "gitdir: "+filepath.Join(repo, ".git", "worktrees", "wt")+"\n")
+	root, gitDir = findGitRoot(worktree)
+	if root != worktree || gitDir != filepath.Join(repo, ".git", "worktrees", "wt") {
+		t.Errorf("Unexpected worktree root %q (git dir %q)", root, gitDir)
+	}
+}
+
+// TestGitResolver_Resolve tests enrichment of files inside and outside a repository
+func TestGitResolver_Resolve(t *testing.T) {
+	const sha = "89abcdef0123456789abcdef0123456789abcdef"
+	repo := t.TempDir()
+	writeGitFile(t, repo, ".git/HEAD", "ref: refs/heads/develop\n")
+	writeGitFile(t, repo, ".git/refs/heads/develop", sha+"\n")
+	writeGitFile(t, repo, "src/index.php", "<?php\n")
+
+	resolver := NewGitResolver()
+	resolver.gitBin = "" // read .git only, without calling git
+
+	info := resolver.Resolve(filepath.Join(repo, "src", "index.php"), 1)
+	if info == nil {
+		t.Fatal("Resolve() should find the repository")
+	}
+	if info.Repo != repo || info.Branch != "develop" || info.Commit != sha || info.Dirty {
+		t.Errorf("Unexpected git info: %+v", info)
+	}
+
+	if resolver.Resolve(filepath.Join(repo, "missing.php"), 1) != nil {
+		t.Error("Resolve() should ignore files that do not exist locally")
+	}
+	if resolver.Resolve("", 0) != nil {
+		t.Error("Resolve() should ignore an empty path")
+	}
+}
+
+// TestParseGitBlame tests reading author and commit from porcelain blame output
+func TestParseGitBlame(t *testing.T) {
+	out := "4f2a9c1e0123456789abcdef0123456789abcdef 12 12 1\n" +
+		"author Jane Doe\n" +
+		"author-mail <jane@example.com>\n" +
+		"author-time 1700000000\n" +
+		"summary Fix totals\n" +
+		"filename src/index.php\n" +
+		"\t$total = 0;\n"
+
+	b := parseGitBlame([]byte(out))
+	if b == nil || b.author != "Jane Doe" || b.email != "jane@example.com" ||
+		b.commit != "4f2a9c1e0123456789abcdef0123456789abcdef" {
+		t.Errorf("Unexpected blame: %+v", b)
+	}
+
+	b = parseGitBlame([]byte("0000000000000000000000000000000000000000 3 3 1\nauthor Not Committed Yet\n\tx\n"))
+	if b == nil || b.commit != "" || b.author != "Not Committed Yet" {
+		t.Errorf("Unexpected blame for an uncommitted line: %+v", b)
+	}
+}
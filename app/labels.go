@@ -0,0 +1,187 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultLabelHistory is how many dumps are kept per label.
+const defaultLabelHistory = 50
+
+// maxLabels bounds the labels indexed at once; beyond it the least recently
+// used unpinned label is forgotten.
+const maxLabels = 500
+
+// LabelSummary describes the dumps received under one label.
+type LabelSummary struct {
+	Label    string       `json:"label"`
+	Count    int          `json:"count"`
+	Pinned   bool         `json:"pinned"`
+	LastSeen time.Time    `json:"last_seen"`
+	Latest   *DumpMessage `json:"latest,omitempty"`
+}
+
+// labelEntry is the per-label state kept by LabelIndex.
+type labelEntry struct {
+	count   int
+	history []*DumpMessage // oldest first, bounded by historyLimit
+	used    uint64         // LabelIndex.tick of the latest dump, for eviction
+}
+
+// LabelIndex keeps the latest dump, a count and a bounded history per label,
+// plus the set of labels the user pinned. At most maxLabels labels are kept.
+type LabelIndex struct {
+	mu           sync.RWMutex
+	entries      map[string]*labelEntry
+	pinned       map[string]bool
+	historyLimit int
+	tick         uint64 // dumps added so far
+}
+
+// NewLabelIndex creates a LabelIndex keeping historyLimit dumps per label.
+func NewLabelIndex(historyLimit int) *LabelIndex {
+	if historyLimit <= 0 {
+		historyLimit = defaultLabelHistory
+	}
+	return &LabelIndex{
+		entries:      make(map[string]*labelEntry),
+		pinned:       make(map[string]bool),
+		historyLimit: historyLimit,
+	}
+}
+
+// Add indexes msg under its label and returns the label's previous latest
+// dump, or nil. Messages without a label are ignored.
+func (x *LabelIndex) Add(msg *DumpMessage) *DumpMessage {
+	if msg.Label == "" {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	entry, ok := x.entries[msg.Label]
+	if !ok {
+		if len(x.entries) >= maxLabels {
+			x.evictLocked()
+		}
+		entry = &labelEntry{}
+		x.entries[msg.Label] = entry
+	}
+	x.tick++
+	entry.used = x.tick
+
+	var previous *DumpMessage
+	if n := len(entry.history); n > 0 {
+		previous = entry.history[n-1]
+	}
+	entry.count++
+	if len(entry.history) >= x.historyLimit {
+		copy(entry.history, entry.history[1:])
+		entry.history = entry.history[:len(entry.history)-1]
+	}
+	entry.history = append(entry.history, msg)
+	return previous
+}
+
+// evictLocked forgets the least recently used unpinned label. Pinned labels
+// are never evicted. Callers hold x.mu.
+func (x *LabelIndex) evictLocked() {
+	oldest, found := "", false
+	for label, entry := range x.entries {
+		if x.pinned[label] {
+			continue
+		}
+		if !found || entry.used < x.entries[oldest].used {
+			oldest, found = label, true
+		}
+	}
+	if found {
+		delete(x.entries, oldest)
+	}
+}
+
+// SetPinned replaces the pinned label set.
+func (x *LabelIndex) SetPinned(labels []string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.pinned = make(map[string]bool, len(labels))
+	for _, label := range labels {
+		x.pinned[label] = true
+	}
+}
+
+// IsPinned reports whether label is pinned.
+func (x *LabelIndex) IsPinned(label string) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.pinned[label]
+}
+
+// Summary returns the summary of one label, or nil if it was never seen.
+func (x *LabelIndex) Summary(label string) *LabelSummary {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	entry, ok := x.entries[label]
+	if !ok {
+		return nil
+	}
+	summary := x.summaryLocked(label, entry)
+	return &summary
+}
+
+// Summaries returns every known label, pinned labels first and then by most
+// recent activity. Pinned labels that have not been seen yet are included
+// with a zero count so the UI can still show them.
+func (x *LabelIndex) Summaries(pinnedOnly bool) []LabelSummary {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	summaries := []LabelSummary{}
+	for label, entry := range x.entries {
+		if pinnedOnly && !x.pinned[label] {
+			continue
+		}
+		summaries = append(summaries, x.summaryLocked(label, entry))
+	}
+	for label := range x.pinned {
+		if _, seen := x.entries[label]; !seen {
+			summaries = append(summaries, LabelSummary{Label: label, Pinned: true})
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Pinned != summaries[j].Pinned {
+			return summaries[i].Pinned
+		}
+		if !summaries[i].LastSeen.Equal(summaries[j].LastSeen) {
+			return summaries[i].LastSeen.After(summaries[j].LastSeen)
+		}
+		return summaries[i].Label < summaries[j].Label
+	})
+	return summaries
+}
+
+// History returns the retained dumps for label, newest first.
+func (x *LabelIndex) History(label string) []*DumpMessage {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	entry, ok := x.entries[label]
+	if !ok {
+		return []*DumpMessage{}
+	}
+	out := make([]*DumpMessage, 0, len(entry.history))
+	for i := len(entry.history) - 1; i >= 0; i-- {
+		out = append(out, entry.history[i])
+	}
+	return out
+}
+
+func (x *LabelIndex) summaryLocked(label string, entry *labelEntry) LabelSummary {
+	summary := LabelSummary{Label: label, Count: entry.count, Pinned: x.pinned[label]}
+	if n := len(entry.history); n > 0 {
+		summary.Latest = entry.history[n-1]
+		summary.LastSeen = summary.Latest.ReceivedAt
+	}
+	return summary
+}
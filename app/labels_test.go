@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newLabeledDump builds a message with the given label
+func newLabeledDump(label string, at time.Time) *DumpMessage {
+	return &DumpMessage{ID: nextDumpID(), Label: label, ReceivedAt: at, Context: label}
+}
+
+// TestLabelIndex_Add tests counting, latest tracking and bounded history
+func TestLabelIndex_Add(t *testing.T) {
+	index := NewLabelIndex(3)
+	base := time.Now()
+
+	var last *DumpMessage
+	for i := 0; i < 5; i++ {
+		msg := newLabeledDump("user", base.Add(time.Duration(i)*time.Second))
+		previous := index.Add(msg)
+		if previous != last {
+			t.Errorf("Add() #%d returned the wrong previous dump", i)
+		}
+		last = msg
+	}
+
+	if index.Add(newLabeledDump("", base)) != nil {
+		t.Error("Unlabeled dumps should not be indexed")
+	}
+
+	summary := index.Summary("user")
+	if summary == nil {
+		t.Fatal("Summary() returned nil for a known label")
+	}
+	if summary.Count != 5 {
+		t.Errorf("Expected count 5, got %d", summary.Count)
+	}
+	if summary.Latest != last {
+		t.Error("Latest should be the most recent dump")
+	}
+
+	history := index.History("user")
+	if len(history) != 3 {
+		t.Fatalf("Expected history bounded to 3, got %d", len(history))
+	}
+	if history[0] != last {
+		t.Error("History should be newest first")
+	}
+
+	if len(index.History("missing")) != 0 {
+		t.Error("History of an unknown label should be empty")
+	}
+}
+
+// TestLabelIndex_Eviction tests that the least recently used unpinned label is dropped at the limit
+func TestLabelIndex_Eviction(t *testing.T) {
+	index := NewLabelIndex(2)
+	index.SetPinned([]string{"label-0"})
+	base := time.Now()
+	for i := 0; i < maxLabels; i++ {
+		index.Add(newLabeledDump(fmt.Sprintf("label-%d", i), base))
+	}
+	index.Add(newLabeledDump("label-1", base))
+	index.Add(newLabeledDump("new", base))
+
+	if n := len(index.Summaries(false)); n != maxLabels {
+		t.Errorf("Expected %d labels, got %d", maxLabels, n)
+	}
+	if index.Summary("label-2") != nil {
+		t.Error("The least recently used unpinned label should be evicted")
+	}
+	for _, label := range []string{"label-0", "label-1", "new"} {
+		if index.Summary(label) == nil {
+			t.Errorf("Label %s should be kept", label)
+		}
+	}
+}
+
+// TestLabelIndex_Pinned tests that pinned labels sort first and are listed even before they are seen
+func TestLabelIndex_Pinned(t *testing.T) {
+	index := NewLabelIndex(10)
+	base := time.Now()
+
+	index.Add(newLabeledDump("old", base))
+	index.Add(newLabeledDump("new", base.Add(time.Minute)))
+	index.SetPinned([]string{"old", "future"})
+
+	all := index.Summaries(false)
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 labels, got %d", len(all))
+	}
+	if !all[0].Pinned || !all[1].Pinned || all[2].Label != "new" {
+		t.Errorf("Pinned labels should come first: %+v", all)
+	}
+	if all[0].Label != "old" {
+		t.Errorf("Seen pinned labels should sort before unseen ones, got '%s'", all[0].Label)
+	}
+
+	pinned := index.Summaries(true)
+	if len(pinned) != 2 {
+		t.Errorf("Expected 2 pinned labels, got %d", len(pinned))
+	}
+
+	index.SetPinned(nil)
+	if index.IsPinned("old") {
+		t.Error("SetPinned(nil) should clear pins")
+	}
+}
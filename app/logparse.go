@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Log folder formats.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json" // one JSON object per line
+)
+
+// LogFieldMapping names the keys holding each part of a JSON log line. Each
+// list is tried in order and dotted keys reach into nested objects.
+type LogFieldMapping struct {
+	Level     []string `yaml:"level,omitempty" json:"level,omitempty"`
+	Message   []string `yaml:"message,omitempty" json:"message,omitempty"`
+	Timestamp []string `yaml:"timestamp,omitempty" json:"timestamp,omitempty"`
+	Context   []string `yaml:"context,omitempty" json:"context,omitempty"` // empty keeps every other field
+}
+
+// defaultLogFields covers Monolog (level_name, message, datetime), zap
+// (level, msg, ts), pino (level, msg, time) and logrus (level, msg, time).
+var defaultLogFields = LogFieldMapping{
+	Level:     []string{"level_name", "level", "severity", "lvl"},
+	Message:   []string{"message", "msg"},
+	Timestamp: []string{"datetime", "timestamp", "time", "ts", "@timestamp"},
+}
+
+// timestampLayouts are tried in order for string timestamps.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"02/Jan/2006:15:04:05 -0700",         // nginx and Apache access
+	"2006/01/02 15:04:05",                // nginx error
+	"Mon Jan 02 15:04:05.999999999 2006", // Apache error
+	"02-Jan-2006 15:04:05.999999999",     // PHP-FPM
+}
+
+// ParsedLogLine is what a parser extracted from one log line.
+type ParsedLogLine struct {
+	Level    string // normalised as detectLogLevel does, "" if unknown
+	Channel  string
+	Message  string
+	LoggedAt time.Time // zero if the line had no timestamp
+	Fields   map[string]interface{}
+}
+
+// fieldMapping returns the folder's mapping, falling back to the defaults
+// for every list it leaves empty.
+func (f *LogFolder) fieldMapping() LogFieldMapping {
+	mapping := defaultLogFields
+	if f.Fields == nil {
+		return mapping
+	}
+	if len(f.Fields.Level) > 0 {
+		mapping.Level = f.Fields.Level
+	}
+	if len(f.Fields.Message) > 0 {
+		mapping.Message = f.Fields.Message
+	}
+	if len(f.Fields.Timestamp) > 0 {
+		mapping.Timestamp = f.Fields.Timestamp
+	}
+	mapping.Context = f.Fields.Context
+	return mapping
+}
+
+// validLogFormat reports whether format is known; "" means text.
+func validLogFormat(format string) bool {
+	switch format {
+	case "", LogFormatText, LogFormatJSON:
+		return true
+	}
+	return false
+}
+
+// parseLogLine applies the folder's format, or parser to text lines. It
+// returns nil when there is neither or the line does not match, so the caller
+// falls back to detectLogLevel.
+func parseLogLine(folder *LogFolder, parser *LogParser, line string) *ParsedLogLine {
+	if folder == nil {
+		return nil
+	}
+	if folder.Format == LogFormatJSON {
+		return parseJSONLogLine(line, folder.fieldMapping())
+	}
+	if parser != nil {
+		return parser.Parse(line)
+	}
+	return nil
+}
+
+// parseJSONLogLine reads line as a JSON object through mapping, or returns
+// nil if it is not one.
+func parseJSONLogLine(line string, mapping LogFieldMapping) *ParsedLogLine {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return nil
+	}
+
+	parsed := &ParsedLogLine{}
+	if key, value, ok := lookupLogField(obj, mapping.Level); ok {
+		parsed.Level = normalizeLogLevel(value)
+		deleteLogField(obj, key)
+	}
+	if key, value, ok := lookupLogField(obj, mapping.Message); ok {
+		if s, isString := value.(string); isString {
+			parsed.Message = s
+		} else {
+			parsed.Message = fmt.Sprint(value)
+		}
+		deleteLogField(obj, key)
+	}
+	if key, value, ok := lookupLogField(obj, mapping.Timestamp); ok {
+		if at, ok := parseLogTimestamp(value); ok {
+			parsed.LoggedAt = at
+			deleteLogField(obj, key)
+		}
+	}
+
+	if len(mapping.Context) == 0 {
+		parsed.Fields = obj
+	} else {
+		parsed.Fields = map[string]interface{}{}
+		for _, key := range mapping.Context {
+			if _, value, ok := lookupLogField(obj, []string{key}); ok {
+				parsed.Fields[key] = value
+			}
+		}
+	}
+	if len(parsed.Fields) == 0 {
+		parsed.Fields = nil
+	}
+	return parsed
+}
+
+// lookupLogField returns the first of keys present in obj, its value and the
+// top-level key to remove once it is consumed ("" for nested keys).
+func lookupLogField(obj map[string]interface{}, keys []string) (string, interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := obj[key]; ok {
+			return key, value, true
+		}
+		if !strings.Contains(key, ".") {
+			continue
+		}
+		var current interface{} = obj
+		found := true
+		for _, part := range strings.Split(key, ".") {
+			m, isObject := current.(map[string]interface{})
+			if !isObject {
+				found = false
+				break
+			}
+			if current, found = m[part]; !found {
+				break
+			}
+		}
+		if found {
+			return "", current, true
+		}
+	}
+	return "", nil, false
+}
+
+// deleteLogField removes a consumed top-level key; nested keys stay in place.
+func deleteLogField(obj map[string]interface{}, key string) {
+	if key != "" {
+		delete(obj, key)
+	}
+}
+
+// normalizeLogLevel maps level names and the numeric levels of Monolog
+// (100-600) and pino (10-60) to the levels detectLogLevel produces.
+func normalizeLogLevel(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "emergency", "emerg", "alert", "critical", "crit", "fatal", "panic", "dpanic", "error", "err":
+			return "error"
+		case "warning", "warn":
+			return "warning"
+		case "notice", "info", "information", "informational":
+			return "info"
+		case "debug", "trace":
+			return "debug"
+		case "success", "ok":
+			return "success"
+		}
+	case float64:
+		if v >= 100 {
+			switch {
+			case v < 200:
+				return "debug"
+			case v < 300:
+				return "info"
+			case v < 400:
+				return "warning"
+			}
+			return "error"
+		}
+		switch {
+		case v <= 20:
+			return "debug"
+		case v < 40:
+			return "info"
+		case v < 50:
+			return "warning"
+		}
+		return "error"
+	}
+	return ""
+}
+
+// parseLogTimestamp reads RFC 3339 and SQL-style strings, Monolog 1 date
+// objects, and epoch numbers in seconds (zap) or milliseconds (pino).
+func parseLogTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range timestampLayouts {
+			if at, err := time.Parse(layout, v); err == nil {
+				return at, true
+			}
+		}
+	case float64:
+		if v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return time.Time{}, false
+		}
+		if v > 1e12 {
+			return time.UnixMilli(int64(v)), true
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	case map[string]interface{}:
+		if date, ok := v["date"].(string); ok {
+			return parseLogTimestamp(date)
+		}
+	}
+	return time.Time{}, false
+}
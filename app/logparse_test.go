@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestParseJSONLogLine tests the default mapping against common JSON loggers
+func TestParseJSONLogLine(t *testing.T) {
+	testCases := []struct {
+		name    string
+		line    string
+		level   string
+		message string
+		at      time.Time
+		fields  int
+	}{
+		{
+			"monolog",
+			`{"message":"User logged in","context":{"id":7},"level":200,"level_name":"INFO","channel":"app","datetime":"2024-05-01T10:00:00.123456+00:00","extra":{}}`,
+			"info", "User logged in", time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC), 4,
+		},
+		{
+			"zap",
+			`{"level":"warn","ts":1714557600.5,"caller":"main.go:12","msg":"slow query","ms":950}`,
+			"warning", "slow query", time.Unix(1714557600, 500000000), 2,
+		},
+		{
+			"pino",
+			`{"level":50,"time":1714557600000,"pid":1,"hostname":"web","msg":"boom"}`,
+			"error", "boom", time.UnixMilli(1714557600000), 2,
+		},
+		{
+			"logrus",
+			`{"level":"debug","msg":"cache miss","time":"2024-05-01T10:00:00Z","key":"users"}`,
+			"debug", "cache miss", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), 1,
+		},
+		{
+			"monolog 1 date object",
+			`{"message":"x","level_name":"CRITICAL","datetime":{"date":"2024-05-01 10:00:00.000000","timezone_type":3,"timezone":"UTC"}}`,
+			"error", "x", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed := parseJSONLogLine(tc.line, defaultLogFields)
+			if parsed == nil {
+				t.Fatal("Expected the line to parse")
+			}
+			if parsed.Level != tc.level || parsed.Message != tc.message {
+				t.Errorf("Expected %s %q, got %s %q", tc.level, tc.message, parsed.Level, parsed.Message)
+			}
+			if !parsed.LoggedAt.Equal(tc.at) {
+				t.Errorf("Expected time %v, got %v", tc.at, parsed.LoggedAt)
+			}
+			if len(parsed.Fields) != tc.fields {
+				t.Errorf("Expected %d fields, got %v", tc.fields, parsed.Fields)
+			}
+		})
+	}
+
+	if parseJSONLogLine("[2024-05-01] local.ERROR: boom", defaultLogFields) != nil {
+		t.Error("Plain text should not parse as JSON")
+	}
+	if parseJSONLogLine(`{"msg": "cut`, defaultLogFields) != nil {
+		t.Error("Broken JSON should not parse")
+	}
+}
+
+// TestParseJSONLogLine_Mapping tests custom and nested field mappings
+func TestParseJSONLogLine_Mapping(t *testing.T) {
+	folder := &LogFolder{Format: LogFormatJSON, Fields: &LogFieldMapping{
+		Level:   []string{"log.level"},
+		Message: []string{"event.text"},
+		Context: []string{"user", "log.logger"},
+	}}
+	parsed := parseLogLine(folder, nil, `{"log":{"level":"error","logger":"db"},"event":{"text":"lost connection"},"user":"ana","@timestamp":"2024-05-01T10:00:00Z"}`)
+	if parsed == nil || parsed.Level != "error" || parsed.Message != "lost connection" {
+		t.Fatalf("Unexpected parse: %+v", parsed)
+	}
+	if parsed.LoggedAt.IsZero() {
+		t.Error("The default timestamp keys should still apply")
+	}
+	if len(parsed.Fields) != 2 || parsed.Fields["user"] != "ana" || parsed.Fields["log.logger"] != "db" {
+		t.Errorf("Expected only the context keys, got %v", parsed.Fields)
+	}
+
+	if parseLogLine(&LogFolder{Format: LogFormatText}, nil, `{"msg":"x"}`) != nil {
+		t.Error("Text folders should not be parsed")
+	}
+	if validLogFormat("xml") || !validLogFormat("") || !validLogFormat(LogFormatJSON) {
+		t.Error("Unexpected format validation")
+	}
+}
+
+// TestLogWatcher_ReadJSONLines tests that json folders emit structured entries
+func TestLogWatcher_ReadJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json.log")
+	content := `{"level":"error","msg":"payment failed","order":42,"ok":true}` + "\n" + "not json, but an error line\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []LogEntry
+	lw := &LogWatcher{
+		files:   map[string]*LogFile{},
+		folders: []LogFolder{{Path: dir, Enabled: true, Format: LogFormatJSON}},
+		onEntry: func(entry LogEntry) { entries = append(entries, entry) },
+	}
+	lw.delivery = NewDeliverer(func(string, interface{}) {})
+	lw.readNewLines(&LogFile{Path: path})
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Level != "error" || entries[0].Message != "payment failed" || entries[0].Fields["order"] != 42.0 {
+		t.Errorf("Unexpected structured entry: %+v", entries[0])
+	}
+	if entries[1].Fields != nil || entries[1].Level != "error" {
+		t.Errorf("Unparsable lines should fall back to text detection: %+v", entries[1])
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// logParserPreset is a built-in parser a LogFolder may name.
+type logParserPreset struct {
+	pattern    string
+	level      string // level of matched lines without a level or status group
+	entryStart string // multi-line entry start used when the folder sets none
+}
+
+// monologLine matches Monolog's LineFormatter as used by Laravel and Symfony:
+// [2024-05-01 10:00:00] local.ERROR: message {"context":1} []
+const monologLine = `^\[(?P<timestamp>\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\] (?P<channel>[\w.-]+)\.(?P<level>[A-Za-z]+): (?P<message>.*?)(?: (?P<context>[{\[].*[}\]]))?$`
+
+// combinedAccessLine matches the combined access log format of nginx and Apache.
+const combinedAccessLine = `^(?P<client>\S+) \S+ (?P<user>\S+) \[(?P<timestamp>[^\]]+)\] "(?P<message>(?P<method>[A-Z]+) (?P<path>\S+)[^"]*)" (?P<status>\d{3}) (?P<bytes>\d+|-)(?: "(?P<referer>[^"]*)" "(?P<agent>[^"]*)")?`
+
+// logParserPresets are the parsers a LogFolder may use instead of a regex.
+var logParserPresets = map[string]logParserPreset{
+	"nginx_access":  {pattern: combinedAccessLine},
+	"apache_access": {pattern: combinedAccessLine},
+	// 2024/05/01 10:00:00 [error] 12#12: *3 open() "/x" failed, client: 1.2.3.4
+	"nginx_error": {pattern: `^(?P<timestamp>\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(?P<level>\w+)\] (?P<pid>\d+)#\d+: (?:\*\d+ )?(?P<message>.*)$`},
+	// [Wed May 01 10:00:00.123456 2024] [core:error] [pid 12] [client 1.2.3.4:5] message
+	"apache_error": {pattern: `^\[(?P<timestamp>[^\]]+)\] \[(?:(?P<channel>[\w-]+):)?(?P<level>\w+)\] (?:\[pid (?P<pid>\d+)(?::tid \d+)?\] )?(?:\[client (?P<client>[^\]]+)\] )?(?P<message>.*)$`},
+	// [01-May-2024 10:00:00]  [pool www] pid 1234, followed by the script and its backtrace
+	"php_fpm_slow": {
+		pattern:    `^\[(?P<timestamp>\d{2}-[A-Za-z]{3}-\d{4} \d{2}:\d{2}:\d{2}(?:\.\d+)?)\]\s+(?:(?P<level>[A-Z]+): )?(?:\[pool (?P<channel>[^\]]+)\]\s*)?(?P<message>.*)$`,
+		level:      "warning",
+		entryStart: `^\[\d{2}-[A-Za-z]{3}-\d{4} `,
+	},
+	"laravel": {pattern: monologLine, entryStart: "bracket_date"},
+	"symfony": {pattern: monologLine, entryStart: "bracket_date"},
+}
+
+// LogParser extracts the fields of a text log line with a regex whose named
+// groups are timestamp, level, channel, message and context, plus any others.
+type LogParser struct {
+	re    *regexp.Regexp
+	level string
+}
+
+// compileLogParser compiles a LogFolder parser: a preset name or a regex
+// with named groups. An empty spec means no parser.
+func compileLogParser(spec string) (*LogParser, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	parser := &LogParser{}
+	if preset, ok := logParserPresets[spec]; ok {
+		spec, parser.level = preset.pattern, preset.level
+	}
+	re, err := regexp.Compile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log parser: %v", err)
+	}
+	named := false
+	for _, name := range re.SubexpNames() {
+		named = named || name != ""
+	}
+	if !named {
+		return nil, fmt.Errorf("log parser %q has no named groups", spec)
+	}
+	parser.re = re
+	return parser, nil
+}
+
+// entryStartSpec returns the folder's entry start, or the one of its parser preset.
+func (f *LogFolder) entryStartSpec() string {
+	if f.EntryStart != "" {
+		return f.EntryStart
+	}
+	return logParserPresets[f.Parser].entryStart
+}
+
+// Parse returns the fields of line, or nil if the parser does not match it.
+func (p *LogParser) Parse(line string) *ParsedLogLine {
+	match := p.re.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+
+	parsed := &ParsedLogLine{}
+	fields := map[string]interface{}{}
+	status := 0
+	for i, name := range p.re.SubexpNames() {
+		value := match[i]
+		if name == "" || value == "" {
+			continue
+		}
+		switch name {
+		case "timestamp":
+			if at, ok := parseLogTimestamp(value); ok {
+				parsed.LoggedAt = at
+			} else {
+				fields[name] = value
+			}
+		case "level":
+			parsed.Level = normalizeLogLevel(value)
+		case "channel":
+			parsed.Channel = value
+		case "message":
+			parsed.Message = value
+		case "context":
+			if context, ok := decodeLogContext(value); ok {
+				fields[name] = context
+			}
+		default:
+			if name == "status" {
+				status, _ = strconv.Atoi(value)
+			}
+			fields[name] = value
+		}
+	}
+
+	if parsed.Level == "" {
+		parsed.Level = statusLevel(status)
+	}
+	if parsed.Level == "" {
+		parsed.Level = p.level
+	}
+	if parsed.Level == "" {
+		parsed.Level = "info"
+	}
+	if len(fields) > 0 {
+		parsed.Fields = fields
+	}
+	return parsed
+}
+
+// statusLevel maps an HTTP status to a level, or "" without one.
+func statusLevel(status int) string {
+	switch {
+	case status >= 500:
+		return "error"
+	case status >= 400:
+		return "warning"
+	case status > 0:
+		return "info"
+	}
+	return ""
+}
+
+// decodeLogContext decodes a Monolog context, dropping the empty extra
+// ("[]") Monolog appends, and keeps anything else as the raw string. It
+// reports false for an empty context.
+func decodeLogContext(raw string) (interface{}, bool) {
+	for _, candidate := range []string{raw, strings.TrimSuffix(raw, " []")} {
+		var value interface{}
+		if err := json.Unmarshal([]byte(candidate), &value); err != nil {
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return v, len(v) > 0
+		case []interface{}:
+			return v, len(v) > 0
+		}
+		return value, true
+	}
+	return raw, true
+}
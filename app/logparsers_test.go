@@ -0,0 +1,223 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogParserPresets tests each preset against a sample line
+func TestLogParserPresets(t *testing.T) {
+	testCases := []struct {
+		preset  string
+		line    string
+		level   string
+		channel string
+		message string
+		at      time.Time
+		field   string
+		value   interface{}
+	}{
+		{
+			"nginx_access",
+			`127.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET /api/users HTTP/1.1" 502 157 "-" "curl/8.0"`,
+			"error", "", "GET /api/users HTTP/1.1", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "path", "/api/users",
+		},
+		{
+			"apache_access",
+			`10.0.0.2 - ana [01/May/2024:10:00:00 +0000] "POST /login HTTP/1.1" 404 -`,
+			"warning", "", "POST /login HTTP/1.1", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "user", "ana",
+		},
+		{
+			"nginx_error",
+			`2024/05/01 10:00:00 [crit] 12#12: *3 connect() failed (111: Connection refused), client: 1.2.3.4`,
+			"error", "", "connect() failed (111: Connection refused), client: 1.2.3.4", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "pid", "12",
+		},
+		{
+			"apache_error",
+			`[Wed May 01 10:00:00.123456 2024] [core:warn] [pid 12] [client 1.2.3.4:5] AH00037: Symbolic link not allowed`,
+			"warning", "core", "AH00037: Symbolic link not allowed", time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC), "client", "1.2.3.4:5",
+		},
+		{
+			"php_fpm_slow",
+			`[01-May-2024 10:00:00]  [pool www] pid 1234`,
+			"warning", "www", "pid 1234", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "", nil,
+		},
+		{
+			"laravel",
+			`[2024-05-01 10:00:00] production.ERROR: Order failed {"order":42} []`,
+			"error", "production", "Order failed", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "context", map[string]interface{}{"order": 42.0},
+		},
+		{
+			"symfony",
+			`[2024-05-01T10:00:00.000000+00:00] doctrine.DEBUG: SELECT 1 [] []`,
+			"debug", "doctrine", "SELECT 1", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "", nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.preset, func(t *testing.T) {
+			parser, err := compileLogParser(tc.preset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed := parser.Parse(tc.line)
+			if parsed == nil {
+				t.Fatal("Expected the line to parse")
+			}
+			if parsed.Level != tc.level || parsed.Channel != tc.channel || parsed.Message != tc.message {
+				t.Errorf("Expected %s %q %q, got %s %q %q", tc.level, tc.channel, tc.message, parsed.Level, parsed.Channel, parsed.Message)
+			}
+			if !parsed.LoggedAt.Equal(tc.at) {
+				t.Errorf("Expected time %v, got %v", tc.at, parsed.LoggedAt)
+			}
+			if tc.field == "" {
+				if _, ok := parsed.Fields["context"]; ok {
+					t.Errorf("Empty contexts should be dropped: %v", parsed.Fields)
+				}
+				return
+			}
+			if got := parsed.Fields[tc.field]; !jsonEqual(got, tc.value) {
+				t.Errorf("Expected %s=%v, got %v", tc.field, tc.value, got)
+			}
+		})
+	}
+}
+
+// jsonEqual compares the decoded values a parser emits
+func jsonEqual(a, b interface{}) bool {
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	if aok != bok {
+		return false
+	}
+	if !aok {
+		return a == b
+	}
+	if len(am) != len(bm) {
+		return false
+	}
+	for k, v := range am {
+		if !jsonEqual(v, bm[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestCompileLogParser tests custom regexes and their validation
+func TestCompileLogParser(t *testing.T) {
+	parser, err := compileLogParser(`^(?P<level>\w+) \| (?P<request_id>\w+) \| (?P<message>.*)$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := parser.Parse("WARN | r42 | disk almost full")
+	if parsed == nil || parsed.Level != "warning" || parsed.Message != "disk almost full" || parsed.Fields["request_id"] != "r42" {
+		t.Errorf("Unexpected parse: %+v", parsed)
+	}
+	if parser.Parse("no separators here") != nil {
+		t.Error("Lines that do not match should not parse")
+	}
+
+	if _, err := compileLogParser(`^(\w+) (.*)$`); err == nil {
+		t.Error("Parsers without named groups should be rejected")
+	}
+	if _, err := compileLogParser("(?P<level>"); err == nil {
+		t.Error("Invalid regexes should be rejected")
+	}
+	if parser, err := compileLogParser(""); parser != nil || err != nil {
+		t.Error("An empty parser means keyword detection")
+	}
+	if (&LogFolder{Parser: "laravel"}).entryStartSpec() != "bracket_date" {
+		t.Error("Presets should bring their entry start")
+	}
+	if (&LogFolder{Parser: "laravel", EntryStart: "iso_date"}).entryStartSpec() != "iso_date" {
+		t.Error("The folder entry start should win over the preset")
+	}
+}
+
+// TestDetectLogLevel_Words tests that keywords only match whole words
+func TestDetectLogLevel_Words(t *testing.T) {
+	testCases := map[string]string{
+		"Refreshed token for user 7":        "info",
+		"Booking confirmed":                 "info",
+		"terror.jpg uploaded":               "info",
+		"Job finished: OK":                  "success",
+		"Connection error: timeout":         "error",
+		"[WARN] cache is cold":              "warning",
+		"user_error() called, err=ENOENT":   "error",
+		"Exceptional results for debugging": "info",
+	}
+	for line, want := range testCases {
+		if got := detectLogLevel(line); got != want {
+			t.Errorf("detectLogLevel(%q) = %s, want %s", line, got, want)
+		}
+	}
+}
+
+// TestDetectLogLevel_ErrorStems tests that exception classes and plurals count as errors
+func TestDetectLogLevel_ErrorStems(t *testing.T) {
+	testCases := map[string]string{
+		"PHP Fatal error:  Uncaught RuntimeException: boom":     "error",
+		"InvalidArgumentException in /app/src/User.php:12":      "error",
+		"#0 /app/vendor/Foo.php(31): Foo\\Bar->baz()":           "info",
+		"Errors: 3 files skipped":                               "error",
+		"ERRORS DETECTED IN BATCH":                              "error",
+		"errno=2":                                               "error",
+		"Illuminate\\Database\\QueryException: SQLSTATE[42S02]": "error",
+		"Exceptional results for debugging":                     "info",
+		"terror.jpg uploaded":                                   "info",
+		"Running an errand for the errata page":                 "info",
+		"Erratic latency on the upstream":                       "info",
+		"err: connection refused":                               "error",
+	}
+	for line, want := range testCases {
+		if got := detectLogLevel(line); got != want {
+			t.Errorf("detectLogLevel(%q) = %s, want %s", line, got, want)
+		}
+	}
+}
+
+// TestLogWatcher_ParserEntries tests that text folders with a parser emit the captured fields
+func TestLogWatcher_ParserEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "laravel.log")
+	content := strings.Join([]string{
+		`[2024-05-01 10:00:00] local.ERROR: Payment declined {"order":42} []`,
+		"[stacktrace]",
+		"#0 {main}",
+		`[2024-05-01 10:00:01] local.INFO: Token refreshed`,
+		"",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := &entryCollector{}
+	lw := newMultilineWatcher(LogFolder{Path: dir, Enabled: true, Parser: "laravel", EntryFlushMs: 60000}, collector)
+	logFile := &LogFile{Path: path}
+	lw.readNewLines(logFile)
+	logFile.mu.Lock()
+	lw.flushPendingLocked(logFile)
+	logFile.mu.Unlock()
+
+	entries := collector.snapshot()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", entries)
+	}
+	first := entries[0]
+	if first.Level != "error" || first.Channel != "local" || first.Message != "Payment declined" || first.Lines != 3 {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+	if context, ok := first.Fields["context"].(map[string]interface{}); !ok || context["order"] != 42.0 {
+		t.Errorf("Expected the decoded context, got %v", first.Fields)
+	}
+	if first.LoggedAt == nil || first.LoggedAt.Minute() != 0 {
+		t.Errorf("Expected the logged time, got %v", first.LoggedAt)
+	}
+	if entries[1].Level != "info" || entries[1].Message != "Token refreshed" {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+}
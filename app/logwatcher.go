@@ -11,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -18,14 +19,22 @@ import (
 
 // LogWatcher monitors log files and directories for changes.
 type LogWatcher struct {
-	appCtx  context.Context // Wails context for logging and event emission
-	cancel  context.CancelFunc
-	watcher *fsnotify.Watcher
-	files   map[string]*LogFile // path -> LogFile
-	folders []LogFolder
-	running bool
-	mu      sync.RWMutex
-	wg      sync.WaitGroup
+	appCtx   context.Context // Wails context for logging and event emission
+	cancel   context.CancelFunc
+	watcher  *fsnotify.Watcher
+	files    map[string]*LogFile // path -> LogFile
+	folders  []LogFolder
+	running  bool
+	mu       sync.RWMutex
+	wg       sync.WaitGroup
+	onEntry  func(LogEntry) // optional hook called for every emitted entry
+	profile  string         // profile the watcher belongs to, copied to entries
+	delivery *Deliverer     // frames entries to the UI; emitted directly when nil
+	metrics  *Metrics       // counts lines read and errors; optional
+
+	patternMu sync.Mutex
+	patterns  map[string]*regexp.Regexp // compiled entry starts; nil for invalid ones
+	parsers   map[string]*LogParser     // compiled folder parsers; nil for invalid ones
 }
 
 // LogFile represents a monitored log file (no persistent file handle).
@@ -35,6 +44,8 @@ type LogFile struct {
 	LastModTime  time.Time
 	LastSize     int64
 	mu           sync.Mutex
+	pending      *pendingEntry // multi-line entry still collecting lines
+	flushTimer   *time.Timer   // releases pending after the folder's flush timeout
 }
 
 // LogEntry represents a single log line with metadata.
@@ -45,6 +56,17 @@ type LogEntry struct {
 	Level     string    `json:"level"`
 	Timestamp time.Time `json:"timestamp"`
 	LineNum   int       `json:"lineNum"`
+	Profile   string    `json:"profile,omitempty"`
+
+	// Set when the folder format or parser parsed the line
+	Channel  string                 `json:"channel,omitempty"`
+	Message  string                 `json:"message,omitempty"`
+	LoggedAt *time.Time             `json:"loggedAt,omitempty"` // the line's own timestamp
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+
+	// Set for multi-line entries: the whole entry, Line being its first line
+	Body  string `json:"body,omitempty"`
+	Lines int    `json:"lines,omitempty"`
 }
 
 // NewLogWatcher creates a new LogWatcher instance.
@@ -87,6 +109,7 @@ func (lw *LogWatcher) Start(folders []LogFolder) error {
 		runtime.LogInfof(lw.appCtx, "Adding folder: %s (extensions: %v)", folder.Path, folder.Extensions)
 		if err := lw.addFolder(folder); err != nil {
 			runtime.LogErrorf(lw.appCtx, "Error adding folder %s: %v", folder.Path, err)
+			lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "add_folder")
 			continue
 		}
 		enabledCount++
@@ -159,6 +182,9 @@ func (lw *LogWatcher) Stop() {
 
 	// Always release OS resources (inotify watches), even if Start was never called.
 	lw.mu.Lock()
+	for _, f := range lw.files {
+		f.discardPending()
+	}
 	lw.files = make(map[string]*LogFile)
 	if lw.watcher != nil {
 		lw.watcher.Close()
@@ -206,6 +232,7 @@ func (lw *LogWatcher) addFolder(folder LogFolder) error {
 	for _, filePath := range files {
 		if err := lw.registerFile(filePath); err != nil {
 			runtime.LogErrorf(lw.appCtx, "Error registering file %s: %v", filePath, err)
+			lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "register")
 		}
 	}
 	return nil
@@ -264,6 +291,7 @@ func (lw *LogWatcher) eventLoop(ctx context.Context) {
 				return
 			}
 			runtime.LogErrorf(lw.appCtx, "Watcher error: %v", err)
+			lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "watch")
 		}
 	}
 }
@@ -328,6 +356,7 @@ func (lw *LogWatcher) handleNewFile(filePath string) {
 					lw.readNewLines(newFile)
 				} else if err != nil {
 					runtime.LogErrorf(lw.appCtx, "Error registering new file %s: %v", filePath, err)
+					lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "register")
 				}
 				return
 			}
@@ -335,14 +364,22 @@ func (lw *LogWatcher) handleNewFile(filePath string) {
 	}
 }
 
-// removeFile removes a file from tracking.
+// removeFile removes a file from tracking, releasing its pending entry.
 func (lw *LogWatcher) removeFile(filePath string) {
 	lw.mu.Lock()
-	defer lw.mu.Unlock()
-	if _, exists := lw.files[filePath]; exists {
+	logFile, exists := lw.files[filePath]
+	if exists {
 		delete(lw.files, filePath)
 		runtime.LogInfof(lw.appCtx, "Stopped monitoring: %s", filePath)
 	}
+	lw.mu.Unlock()
+
+	// Outside lw.mu: readNewLines takes logFile.mu before lw.mu
+	if exists {
+		logFile.mu.Lock()
+		lw.flushPendingLocked(logFile)
+		logFile.mu.Unlock()
+	}
 }
 
 // readNewLines opens the file, reads any new lines since the last position,
@@ -355,6 +392,7 @@ func (lw *LogWatcher) readNewLines(logFile *LogFile) {
 	if err != nil {
 		if !os.IsNotExist(err) {
 			runtime.LogErrorf(lw.appCtx, "Stat error for %s: %v", logFile.Path, err)
+			lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "stat")
 		}
 		return
 	}
@@ -375,12 +413,14 @@ func (lw *LogWatcher) readNewLines(logFile *LogFile) {
 	file, err := os.OpenFile(logFile.Path, os.O_RDONLY, 0)
 	if err != nil {
 		runtime.LogErrorf(lw.appCtx, "Open error for %s: %v", logFile.Path, err)
+		lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "open")
 		return
 	}
 	defer file.Close()
 
 	if _, err = file.Seek(logFile.LastPosition, io.SeekStart); err != nil {
 		runtime.LogErrorf(lw.appCtx, "Seek error for %s: %v", logFile.Path, err)
+		lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "seek")
 		return
 	}
 
@@ -399,10 +439,21 @@ func (lw *LogWatcher) readNewLines(logFile *LogFile) {
 	const maxScanTokenSize = 1024 * 1024 // 1 MB per line
 	const maxLinesPerRead = 1000
 
+	// Lines are counted per watched folder: file names change with rotation
+	// and would give the metric an unbounded number of series
+	metricFolder := "other"
+	if folder != nil {
+		metricFolder = folder.Path
+	}
+
 	buf := make([]byte, 0, 64*1024)
 	scanner := bufio.NewScanner(file)
 	scanner.Buffer(buf, maxScanTokenSize)
 
+	// With an entry-start pattern, continuation lines join the entry they
+	// follow and the last entry waits for more lines or the flush timeout
+	start := lw.entryStart(folder)
+
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
@@ -412,24 +463,18 @@ func (lw *LogWatcher) readNewLines(logFile *LogFile) {
 		}
 
 		line := scanner.Text()
-		level := detectLogLevel(line)
-
-		if folder != nil && len(folder.Filters) > 0 && !matchesFilter(level, folder.Filters) {
+		lw.metrics.Inc(metricLogLinesRead, lw.profile, metricFolder)
+		if start != nil {
+			lw.addEntryLineLocked(logFile, folder, start, line, lineNum)
 			continue
 		}
-
-		runtime.EventsEmit(lw.appCtx, "logLine", LogEntry{
-			FilePath:  logFile.Path,
-			FileName:  filepath.Base(logFile.Path),
-			Line:      line,
-			Level:     level,
-			Timestamp: time.Now(),
-			LineNum:   lineNum,
-		})
+		lw.emitEntry(logFile, folder, line, "", 1, lineNum)
 	}
+	lw.schedulePendingFlushLocked(logFile)
 
 	if err := scanner.Err(); err != nil {
 		runtime.LogErrorf(lw.appCtx, "Scanner error for %s: %v", logFile.Path, err)
+		lw.metrics.Inc(metricLogWatcherErrors, lw.profile, "scan")
 	}
 
 	// Update position to where the scanner left off.
@@ -440,34 +485,105 @@ func (lw *LogWatcher) readNewLines(logFile *LogFile) {
 	}
 }
 
-// detectLogLevel infers a log level from the content of a line.
+// emitEntry parses, filters and emits one entry of logFile: line is its
+// first line and body the whole entry when it spans several lines.
+func (lw *LogWatcher) emitEntry(logFile *LogFile, folder *LogFolder, line, body string, lines, lineNum int) {
+	text := line
+	if body != "" && folder != nil && folder.Format == LogFormatJSON {
+		text = body // a pretty-printed object spans the whole entry
+	}
+	parsed := parseLogLine(folder, lw.logParser(folder), text)
+	level := ""
+	if parsed != nil {
+		level = parsed.Level
+	}
+	if level == "" {
+		// The first line carries the level; stack frames would only add noise
+		level = detectLogLevel(line)
+	}
+
+	if folder != nil && len(folder.Filters) > 0 && !matchesFilter(level, folder.Filters) {
+		return
+	}
+
+	entry := LogEntry{
+		FilePath:  logFile.Path,
+		FileName:  filepath.Base(logFile.Path),
+		Line:      line,
+		Level:     level,
+		Timestamp: time.Now(),
+		LineNum:   lineNum,
+		Profile:   lw.profile,
+	}
+	if lines > 1 {
+		entry.Body, entry.Lines = body, lines
+	}
+	if parsed != nil {
+		entry.Channel = parsed.Channel
+		entry.Message = parsed.Message
+		entry.Fields = parsed.Fields
+		if !parsed.LoggedAt.IsZero() {
+			entry.LoggedAt = &parsed.LoggedAt
+		}
+	}
+	if lw.delivery != nil {
+		lw.delivery.Push("logLine", entry)
+	} else {
+		runtime.EventsEmit(lw.appCtx, "logLine", entry)
+	}
+	if lw.onEntry != nil {
+		lw.onEntry(entry)
+	}
+}
+
+// detectLogLevel infers a log level from the words of a line, for lines no
+// format or parser could read. Keywords match whole words, so "token" or
+// "terror" do not count as "ok" or "err"; "ERRORS", "errno" and exception
+// class names such as "RuntimeException" still count as errors.
 func detectLogLevel(line string) string {
-	lower := strings.ToLower(line)
+	words := strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
 	switch {
-	case containsAny(lower, "error", "err", "fatal", "critical", "exception"):
+	case containsAny(words, "fatal", "critical") || containsErrorStem(words):
 		return "error"
-	case containsAny(lower, "warning", "warn"):
+	case containsAny(words, "warning", "warn"):
 		return "warning"
-	case containsAny(lower, "debug", "trace"):
+	case containsAny(words, "debug", "trace"):
 		return "debug"
-	case containsAny(lower, "success", "ok", "passed"):
+	case containsAny(words, "success", "ok", "passed"):
 		return "success"
-	case containsAny(lower, "info", "information"):
+	case containsAny(words, "info", "information"):
 		return "info"
 	default:
 		return "info"
 	}
 }
 
-func containsAny(s string, keywords ...string) bool {
-	for _, kw := range keywords {
-		if strings.Contains(s, kw) {
-			return true
+// containsAny reports whether any of keywords is among words.
+func containsAny(words []string, keywords ...string) bool {
+	for _, word := range words {
+		for _, kw := range keywords {
+			if word == kw {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// containsErrorStem reports whether a word is an error keyword or ends in
+// "exception" (an exception class name). Other words starting with "err",
+// such as "errand" or "erratic", do not count.
+func containsErrorStem(words []string) bool {
+	for _, word := range words {
+		if strings.HasSuffix(word, "exception") {
+			return true
+		}
+	}
+	return containsAny(words, "err", "error", "errors", "errno")
+}
+
 // matchesFilter reports whether level is in the filter list.
 func matchesFilter(level string, filters []string) bool {
 	if len(filters) == 0 {
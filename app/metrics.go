@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names exposed on /metrics.
+const (
+	metricMessagesReceived = "versadumps_messages_received_total"
+	metricMessagesRejected = "versadumps_messages_rejected_total"
+	metricPayloadBytes     = "versadumps_payload_bytes"
+	metricRequestDuration  = "versadumps_http_request_duration_seconds"
+	metricEmitLatency      = "versadumps_emit_latency_seconds"
+	metricLogLinesRead     = "versadumps_log_lines_read_total"
+	metricLogWatcherErrors = "versadumps_log_watcher_errors_total"
+	metricUpdateChecks     = "versadumps_update_checks_total"
+)
+
+// Rejection reasons counted on metricMessagesRejected besides the access
+// policy ones (RejectUnauthorized, RejectIP, RejectOrigin).
+const (
+	RejectEncoding = "encoding" // unsupported or corrupt Content-Encoding
+	RejectTooLarge = "too_large"
+	RejectInvalid  = "invalid" // not a valid dump payload
+)
+
+// Update check results counted on metricUpdateChecks.
+const (
+	UpdateCheckAvailable   = "available"
+	UpdateCheckUpToDate    = "up_to_date"
+	UpdateCheckRateLimited = "rate_limited"
+	UpdateCheckError       = "error"
+)
+
+var (
+	// byteBuckets cover payloads from a short string to the default 10MB limit
+	byteBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216}
+	// latencyBuckets cover a local round trip to a UI that fell far behind
+	latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+)
+
+// metricFamily is one named counter or histogram and its labelled series.
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string // "counter" or "histogram"
+	labels  []string
+	buckets []float64
+	series  map[string]*metricSeries // joined label values -> series
+}
+
+type metricSeries struct {
+	values []string
+	value  float64  // counters
+	counts []uint64 // histograms, per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Metrics collects counters and histograms about the visualizer itself and
+// writes them in the Prometheus text format. A nil *Metrics records nothing,
+// so components can be used without one.
+type Metrics struct {
+	mu       sync.Mutex
+	families map[string]*metricFamily
+}
+
+// NewMetrics creates the registry with every family the app records.
+func NewMetrics() *Metrics {
+	m := &Metrics{families: make(map[string]*metricFamily)}
+	m.counter(metricMessagesReceived, "Dumps accepted by the HTTP endpoints.", "profile", "endpoint")
+	m.counter(metricMessagesRejected, "Requests and dumps refused, by reason.", "profile", "reason")
+	m.histogram(metricPayloadBytes, "Decompressed size of accepted request bodies.", byteBuckets, "endpoint")
+	m.histogram(metricRequestDuration, "Time spent handling HTTP requests.", latencyBuckets, "path")
+	m.histogram(metricEmitLatency, "Time messages wait in the delivery buffer before reaching the UI.", latencyBuckets, "event")
+	m.counter(metricLogLinesRead, "Lines read from watched log files, by watched folder.", "profile", "folder")
+	m.counter(metricLogWatcherErrors, "Errors while watching or reading log files, by operation.", "profile", "op")
+	m.counter(metricUpdateChecks, "Update checks against GitHub, by result.", "result")
+	return m
+}
+
+func (m *Metrics) counter(name, help string, labels ...string) {
+	m.families[name] = &metricFamily{name: name, help: help, kind: "counter", labels: labels, series: make(map[string]*metricSeries)}
+}
+
+func (m *Metrics) histogram(name, help string, buckets []float64, labels ...string) {
+	m.families[name] = &metricFamily{name: name, help: help, kind: "histogram", labels: labels, buckets: buckets, series: make(map[string]*metricSeries)}
+}
+
+// seriesLocked returns the series of name for the label values, creating it
+// if needed, or nil for an unknown family or a wrong number of values.
+func (m *Metrics) seriesLocked(name string, values []string) (*metricFamily, *metricSeries) {
+	family, ok := m.families[name]
+	if !ok || len(values) != len(family.labels) {
+		return nil, nil
+	}
+	key := strings.Join(values, "\x00")
+	series, ok := family.series[key]
+	if !ok {
+		series = &metricSeries{values: append([]string(nil), values...)}
+		if family.kind == "histogram" {
+			series.counts = make([]uint64, len(family.buckets))
+		}
+		family.series[key] = series
+	}
+	return family, series
+}
+
+// Inc adds one to the counter name.
+func (m *Metrics) Inc(name string, values ...string) {
+	m.Add(name, 1, values...)
+}
+
+// Add adds delta to the counter name.
+func (m *Metrics) Add(name string, delta float64, values ...string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if family, series := m.seriesLocked(name, values); family != nil && family.kind == "counter" {
+		series.value += delta
+	}
+}
+
+// Observe records v in the histogram name.
+func (m *Metrics) Observe(name string, v float64, values ...string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	family, series := m.seriesLocked(name, values)
+	if family == nil || family.kind != "histogram" {
+		return
+	}
+	if i := sort.SearchFloat64s(family.buckets, v); i < len(family.buckets) {
+		series.counts[i]++
+	}
+	series.sum += v
+	series.count++
+}
+
+// ObserveSince records the seconds elapsed since start in the histogram name.
+func (m *Metrics) ObserveSince(name string, start time.Time, values ...string) {
+	m.Observe(name, time.Since(start).Seconds(), values...)
+}
+
+// Value returns the counter value or the histogram count of one series.
+func (m *Metrics) Value(name string, values ...string) float64 {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	family, ok := m.families[name]
+	if !ok {
+		return 0
+	}
+	series, ok := family.series[strings.Join(values, "\x00")]
+	if !ok {
+		return 0
+	}
+	if family.kind == "histogram" {
+		return float64(series.count)
+	}
+	return series.value
+}
+
+// WriteTo writes every family in the Prometheus text exposition format,
+// families and series sorted for stable output.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := m.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, family.help, name, family.kind)
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			series := family.series[key]
+			labels := formatLabels(family.labels, series.values)
+			if family.kind == "counter" {
+				fmt.Fprintf(&b, "%s%s %s\n", name, labels, formatMetricValue(series.value))
+				continue
+			}
+			bucketNames := append(append([]string(nil), family.labels...), "le")
+			bucketValues := append(append([]string(nil), series.values...), "")
+			cumulative := uint64(0)
+			for i, upper := range family.buckets {
+				cumulative += series.counts[i]
+				bucketValues[len(bucketValues)-1] = formatMetricValue(upper)
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, formatLabels(bucketNames, bucketValues), cumulative)
+			}
+			bucketValues[len(bucketValues)-1] = "+Inf"
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, formatLabels(bucketNames, bucketValues), series.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, labels, formatMetricValue(series.sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, labels, series.count)
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeMetricSample writes a single unlabelled sample with its HELP and TYPE
+// lines, for values read from other components at scrape time.
+func writeMetricSample(w io.Writer, name, kind, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, formatMetricValue(value))
+}
+
+// labelEscaper escapes label values as the text format expects.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders {name="value",...}, or "" without labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatMetricValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// instrumentHandler records the duration of every request under the mux
+// pattern it was routed to, keeping the path label bounded.
+func (m *Metrics) instrumentHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			pattern = "other"
+		}
+		mux.ServeHTTP(w, r)
+		m.ObserveSince(metricRequestDuration, start, pattern)
+	})
+}
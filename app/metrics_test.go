@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMetrics_Exposition tests counters and histograms in the text format
+func TestMetrics_Exposition(t *testing.T) {
+	m := NewMetrics()
+	m.Inc(metricMessagesReceived, "default", "/data")
+	m.Add(metricMessagesReceived, 2, "default", "/data")
+	m.Inc(metricMessagesRejected, "default", RejectInvalid)
+	m.Observe(metricPayloadBytes, 100, "/data")
+	m.Observe(metricPayloadBytes, 5000, "/data")
+	m.Inc(metricUpdateChecks, UpdateCheckUpToDate)
+	m.Inc(metricLogLinesRead, "default", `C:\logs\"app"`)
+
+	// Unknown families and wrong label counts are ignored
+	m.Inc("versadumps_unknown_total")
+	m.Inc(metricMessagesReceived, "default")
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE versadumps_messages_received_total counter\n",
+		`versadumps_messages_received_total{profile="default",endpoint="/data"} 3` + "\n",
+		`versadumps_messages_rejected_total{profile="default",reason="invalid"} 1` + "\n",
+		"# TYPE versadumps_payload_bytes histogram\n",
+		`versadumps_payload_bytes_bucket{endpoint="/data",le="256"} 1` + "\n",
+		`versadumps_payload_bytes_bucket{endpoint="/data",le="4096"} 1` + "\n",
+		`versadumps_payload_bytes_bucket{endpoint="/data",le="16384"} 2` + "\n",
+		`versadumps_payload_bytes_bucket{endpoint="/data",le="+Inf"} 2` + "\n",
+		`versadumps_payload_bytes_sum{endpoint="/data"} 5100` + "\n",
+		`versadumps_payload_bytes_count{endpoint="/data"} 2` + "\n",
+		`versadumps_update_checks_total{result="up_to_date"} 1` + "\n",
+		`folder="C:\\logs\\\"app\""`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "unknown") {
+		t.Error("Unknown families should not be written")
+	}
+	if v := m.Value(metricPayloadBytes, "/data"); v != 2 {
+		t.Errorf("Expected a histogram count of 2, got %v", v)
+	}
+
+	// A nil registry records nothing and does not panic
+	var none *Metrics
+	none.Inc(metricMessagesReceived, "default", "/data")
+	if none.Value(metricMessagesReceived, "default", "/data") != 0 {
+		t.Error("A nil registry should report zero")
+	}
+}
+
+// TestMetrics_Instrumentation tests request durations, emit latency and log lines read
+func TestMetrics_Instrumentation(t *testing.T) {
+	m := NewMetrics()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := m.instrumentHandler(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope/1", nil))
+	if m.Value(metricRequestDuration, "/health") != 1 {
+		t.Error("Expected the /health request to be timed")
+	}
+	if m.Value(metricRequestDuration, "other") != 1 {
+		t.Error("Unrouted paths should share one label")
+	}
+
+	recorder := &eventRecorder{}
+	d := NewDeliverer(recorder.emit)
+	d.metrics = m
+	d.Push("newData", "x")
+	waitDelivered(t, d)
+	if m.Value(metricEmitLatency, "newData") != 1 {
+		t.Error("Expected the emit latency of the delivered dump")
+	}
+
+	dir := t.TempDir()
+	lw := &LogWatcher{files: map[string]*LogFile{}, folders: []LogFolder{{Path: dir}}, profile: "default", delivery: d, metrics: m}
+	for _, name := range []string{"app-2024-05-01.log", "app-2024-05-02.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lw.readNewLines(&LogFile{Path: path, LastModTime: time.Now()})
+	}
+	if v := m.Value(metricLogLinesRead, "default", dir); v != 6 {
+		t.Errorf("Expected 6 lines read in the folder, got %v", v)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// entryStartPresets are the named entry-start patterns a LogFolder may use
+// instead of a regex.
+var entryStartPresets = map[string]string{
+	"bracket_date": `^\[\d{4}-\d{2}-\d{2}`,                     // [2024-05-01 10:00:00] local.ERROR: ... (Laravel, Monolog)
+	"laravel":      `^\[\d{4}-\d{2}-\d{2}`,                     // alias of bracket_date
+	"iso_date":     `^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}`,        // 2024-05-01T10:00:00 ...
+	"syslog":       `^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`, // May  1 10:00:00 host ...
+	"json":         `^\{`,                                      // one JSON object per entry
+}
+
+// Multi-line assembly limits.
+const (
+	defaultEntryFlush = time.Second // release the last entry of a file after this much quiet
+	maxEntryLines     = 1000        // continuation lines kept per entry
+)
+
+// entryStartPattern compiles a LogFolder entry start: a preset name or a
+// regular expression. An empty spec means every line is an entry.
+func entryStartPattern(spec string) (*regexp.Regexp, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	if preset, ok := entryStartPresets[spec]; ok {
+		spec = preset
+	}
+	re, err := regexp.Compile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry start pattern: %v", err)
+	}
+	return re, nil
+}
+
+// entryFlushTimeout returns how long the folder waits before releasing the
+// last entry of a file.
+func (f *LogFolder) entryFlushTimeout() time.Duration {
+	if f.EntryFlushMs > 0 {
+		return time.Duration(f.EntryFlushMs) * time.Millisecond
+	}
+	return defaultEntryFlush
+}
+
+// pendingEntry is a multi-line entry still collecting continuation lines.
+type pendingEntry struct {
+	folder  *LogFolder
+	lines   []string
+	lineNum int
+	dropped int // continuation lines past maxEntryLines
+}
+
+func (p *pendingEntry) add(line string) {
+	if len(p.lines) >= maxEntryLines {
+		p.dropped++
+		return
+	}
+	p.lines = append(p.lines, line)
+}
+
+// entryStart returns the compiled entry-start pattern of folder, or nil when
+// its lines are single entries. Patterns are compiled once per watcher;
+// invalid ones are logged and ignored.
+func (lw *LogWatcher) entryStart(folder *LogFolder) *regexp.Regexp {
+	if folder == nil || folder.entryStartSpec() == "" {
+		return nil
+	}
+	spec := folder.entryStartSpec()
+	lw.patternMu.Lock()
+	defer lw.patternMu.Unlock()
+	if re, ok := lw.patterns[spec]; ok {
+		return re
+	}
+	re, err := entryStartPattern(spec)
+	if err != nil {
+		runtime.LogErrorf(lw.appCtx, "Folder %s: %v; reading single lines", folder.Path, err)
+	}
+	if lw.patterns == nil {
+		lw.patterns = make(map[string]*regexp.Regexp)
+	}
+	lw.patterns[spec] = re
+	return re
+}
+
+// logParser returns the compiled parser of folder, or nil when it has none.
+// Parsers are compiled once per watcher; invalid ones are logged and ignored.
+func (lw *LogWatcher) logParser(folder *LogFolder) *LogParser {
+	if folder == nil || folder.Parser == "" {
+		return nil
+	}
+	lw.patternMu.Lock()
+	defer lw.patternMu.Unlock()
+	if parser, ok := lw.parsers[folder.Parser]; ok {
+		return parser
+	}
+	parser, err := compileLogParser(folder.Parser)
+	if err != nil {
+		runtime.LogErrorf(lw.appCtx, "Folder %s: %v; detecting levels by keyword", folder.Path, err)
+	}
+	if lw.parsers == nil {
+		lw.parsers = make(map[string]*LogParser)
+	}
+	lw.parsers[folder.Parser] = parser
+	return parser
+}
+
+// addEntryLineLocked feeds one line of logFile to its pending entry: a line
+// matching start releases the previous entry and begins a new one, any other
+// line continues it. Callers hold logFile.mu.
+func (lw *LogWatcher) addEntryLineLocked(logFile *LogFile, folder *LogFolder, start *regexp.Regexp, line string, lineNum int) {
+	if logFile.pending != nil && !start.MatchString(line) {
+		logFile.pending.add(line)
+		return
+	}
+	lw.flushPendingLocked(logFile)
+	logFile.pending = &pendingEntry{folder: folder, lines: []string{line}, lineNum: lineNum}
+}
+
+// flushPendingLocked emits the pending entry of logFile, if any. Callers
+// hold logFile.mu.
+func (lw *LogWatcher) flushPendingLocked(logFile *LogFile) {
+	pending := logFile.pending
+	if pending == nil {
+		return
+	}
+	logFile.pending = nil
+	if logFile.flushTimer != nil {
+		logFile.flushTimer.Stop()
+	}
+
+	body := ""
+	if len(pending.lines) > 1 || pending.dropped > 0 {
+		body = strings.Join(pending.lines, "\n")
+		if pending.dropped > 0 {
+			body += fmt.Sprintf("\n... %d more lines", pending.dropped)
+		}
+	}
+	lw.emitEntry(logFile, pending.folder, pending.lines[0], body, len(pending.lines)+pending.dropped, pending.lineNum)
+}
+
+// schedulePendingFlushLocked releases the pending entry of logFile once no
+// line has continued it for the folder's flush timeout. Callers hold
+// logFile.mu.
+func (lw *LogWatcher) schedulePendingFlushLocked(logFile *LogFile) {
+	if logFile.pending == nil {
+		return
+	}
+	timeout := logFile.pending.folder.entryFlushTimeout()
+	if logFile.flushTimer != nil {
+		logFile.flushTimer.Reset(timeout)
+		return
+	}
+	logFile.flushTimer = time.AfterFunc(timeout, func() {
+		logFile.mu.Lock()
+		defer logFile.mu.Unlock()
+		lw.flushPendingLocked(logFile)
+	})
+}
+
+// discardPending drops the pending entry of f and its flush timer.
+func (f *LogFile) discardPending() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = nil
+	if f.flushTimer != nil {
+		f.flushTimer.Stop()
+		f.flushTimer = nil
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// entryCollector records the entries a test watcher emits
+type entryCollector struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func (c *entryCollector) add(entry LogEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+}
+
+func (c *entryCollector) snapshot() []LogEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]LogEntry(nil), c.entries...)
+}
+
+// newMultilineWatcher returns a watcher over dir that never touches the Wails runtime
+func newMultilineWatcher(folder LogFolder, collector *entryCollector) *LogWatcher {
+	return &LogWatcher{
+		files:    map[string]*LogFile{},
+		folders:  []LogFolder{folder},
+		delivery: NewDeliverer(func(string, interface{}) {}),
+		onEntry:  collector.add,
+	}
+}
+
+// TestLogWatcher_MultilineEntries tests joining stack traces to their first line
+func TestLogWatcher_MultilineEntries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "laravel.log")
+	content := strings.Join([]string{
+		"[2024-05-01 10:00:00] local.ERROR: Division by zero {\"exception\":\"[object] (DivisionByZeroError(code: 0): Division by zero at /app/Http/Controllers/Math.php:12)",
+		"[stacktrace]",
+		"#0 /app/vendor/laravel/framework/src/Illuminate/Routing/Controller.php(54): divide()",
+		"#1 {main}",
+		"\"}",
+		"[2024-05-01 10:00:01] local.INFO: Request handled",
+		"",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := &entryCollector{}
+	lw := newMultilineWatcher(LogFolder{Path: dir, Enabled: true, EntryStart: "laravel", EntryFlushMs: 20}, collector)
+	logFile := &LogFile{Path: path}
+	lw.readNewLines(logFile)
+
+	// The first entry is released by the second; the second waits for the timeout
+	entries := collector.snapshot()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry before the flush timeout, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.Level != "error" || first.Lines != 5 || first.LineNum != 1 {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+	if !strings.HasPrefix(first.Line, "[2024-05-01 10:00:00]") || !strings.HasSuffix(first.Body, "\"}") {
+		t.Errorf("Expected the first line and the whole body, got %q / %q", first.Line, first.Body)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(collector.snapshot()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("The last entry was not flushed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	second := collector.snapshot()[1]
+	if second.Level != "info" || second.Lines != 0 || second.Body != "" {
+		t.Errorf("A single-line entry should stay a plain line: %+v", second)
+	}
+}
+
+// TestLogWatcher_MultilineAcrossReads tests continuation lines written after the first read
+func TestLogWatcher_MultilineAcrossReads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("2024-05-01T10:00:00 WARN slow\n  at step one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := &entryCollector{}
+	lw := newMultilineWatcher(LogFolder{Path: dir, Enabled: true, EntryStart: "iso_date", EntryFlushMs: 60000}, collector)
+	logFile := &LogFile{Path: path}
+	lw.readNewLines(logFile)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("  at step two\n2024-05-01T10:00:05 INFO done\n")
+	f.Close()
+	lw.readNewLines(logFile)
+
+	entries := collector.snapshot()
+	if len(entries) != 1 || entries[0].Lines != 3 || entries[0].Level != "warning" {
+		t.Fatalf("Expected the warning joined across reads, got %+v", entries)
+	}
+
+	// Releasing the file (as removeFile does) emits its pending entry
+	logFile.mu.Lock()
+	lw.flushPendingLocked(logFile)
+	logFile.mu.Unlock()
+	if entries := collector.snapshot(); len(entries) != 2 || entries[1].Line != "2024-05-01T10:00:05 INFO done" {
+		t.Errorf("Expected the pending entry once released, got %+v", entries)
+	}
+
+	logFile.discardPending()
+	if logFile.pending != nil || logFile.flushTimer != nil {
+		t.Error("discardPending should drop the entry and its timer")
+	}
+}
+
+// TestEntryStartPattern tests presets and regex validation
+func TestEntryStartPattern(t *testing.T) {
+	re, err := entryStartPattern("bracket_date")
+	if err != nil || !re.MatchString("[2024-05-01 10:00:00] x") || re.MatchString("#0 {main}") {
+		t.Errorf("Unexpected bracket_date preset: %v", err)
+	}
+	re, err = entryStartPattern(`^\d+ `)
+	if err != nil || !re.MatchString("42 started") {
+		t.Errorf("Custom patterns should compile: %v", err)
+	}
+	if re, err := entryStartPattern(""); re != nil || err != nil {
+		t.Error("An empty pattern means one entry per line")
+	}
+	if _, err := entryStartPattern("(["); err == nil {
+		t.Error("Invalid patterns should be rejected")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !freebsd && !openbsd && !netbsd
+
+package main
+
+// newNotifier returns a no-op notifier; native notifications are only
+// implemented for freedesktop.org desktops so far.
+func newNotifier() Notifier {
+	return noopNotifier{}
+}
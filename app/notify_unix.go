@@ -0,0 +1,54 @@
+//go:build linux || freebsd || openbsd || netbsd
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// notifyTimeout bounds how long the notification service may take to answer.
+const notifyTimeout = 2 * time.Second
+
+// dbusNotifier sends notifications through the freedesktop.org
+// org.freedesktop.Notifications service on the session bus. It connects on
+// the first notification and does nothing in sessions without a bus (e.g.
+// over SSH).
+type dbusNotifier struct {
+	once sync.Once
+	conn *dbus.Conn // nil when there is no session bus
+}
+
+// newNotifier returns the D-Bus notifier.
+func newNotifier() Notifier {
+	return &dbusNotifier{}
+}
+
+func (n *dbusNotifier) Notify(title, body string) error {
+	var connErr error
+	n.once.Do(func() {
+		n.conn, connErr = dbus.SessionBus()
+	})
+	if n.conn == nil {
+		// Only the first attempt reports the missing bus
+		return connErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	obj := n.conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	call := obj.CallWithContext(ctx, "org.freedesktop.Notifications.Notify", 0,
+		"VersaDumps",              // app_name
+		uint32(0),                 // replaces_id
+		"",                        // app_icon
+		title,                     // summary
+		body,                      // body
+		[]string{},                // actions
+		map[string]dbus.Variant{}, // hints
+		int32(5000),               // expire_timeout (ms)
+	)
+	return call.Err
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Default payload limits, used when a profile does not set its own.
+const (
+	defaultMaxBodyMB    = 10
+	defaultMaxJSONDepth = 128
+	defaultMaxJSONKeys  = 200000
+)
+
+// truncatedKey is added to objects that lost members to a payload limit.
+const truncatedKey = "__truncated__"
+
+// PayloadLimits bounds how much of a request body is decoded. MaxBytes
+// applies to the body after decompression; MaxKeys counts object members
+// and array elements across the whole payload.
+type PayloadLimits struct {
+	MaxBytes int64
+	MaxDepth int
+	MaxKeys  int
+}
+
+// defaultPayloadLimits returns the limits used when none are configured.
+func defaultPayloadLimits() PayloadLimits {
+	return PayloadLimits{
+		MaxBytes: defaultMaxBodyMB << 20,
+		MaxDepth: defaultMaxJSONDepth,
+		MaxKeys:  defaultMaxJSONKeys,
+	}
+}
+
+// profilePayloadLimits returns the payload limits of profile.
+func profilePayloadLimits(profile Profile) PayloadLimits {
+	limits := defaultPayloadLimits()
+	if profile.MaxBodyMB > 0 {
+		limits.MaxBytes = int64(profile.MaxBodyMB) << 20
+	}
+	if profile.MaxJSONDepth > 0 {
+		limits.MaxDepth = profile.MaxJSONDepth
+	}
+	if profile.MaxJSONKeys > 0 {
+		limits.MaxKeys = profile.MaxJSONKeys
+	}
+	return limits
+}
+
+// errUnsupportedEncoding is returned for a Content-Encoding we cannot decode.
+var errUnsupportedEncoding = errors.New("unsupported Content-Encoding")
+
+// zstdMinWindow is the smallest zstd window the decoder accepts whatever the
+// body limit: the default window of common encoders streaming a body.
+const zstdMinWindow = 8 << 20
+
+// decodedBody returns r.Body behind the decompressor named by its
+// Content-Encoding. maxBytes is the decompressed body limit, which also
+// bounds the memory a zstd decoder may claim. The caller closes the returned
+// reader to release the decompressor; r.Body is closed by the server as usual.
+func decodedBody(r *http.Request, maxBytes int64) (io.ReadCloser, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return io.NopCloser(r.Body), nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, &PayloadError{Message: fmt.Sprintf("invalid gzip body: %v", err)}
+		}
+		return zr, nil
+	case "zstd":
+		maxMemory := uint64(maxBytes)
+		if maxMemory < zstdMinWindow {
+			maxMemory = zstdMinWindow
+		}
+		zr, err := zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxMemory))
+		if err != nil {
+			return nil, &PayloadError{Message: fmt.Sprintf("invalid zstd body: %v", err)}
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%w '%s'", errUnsupportedEncoding, encoding)
+	}
+}
+
+// limitBody caps r at limit bytes. Reading past the cap fails with an
+// *http.MaxBytesError, like http.MaxBytesReader does for the raw body, so
+// a small compressed body cannot expand without bound.
+func limitBody(r io.Reader, limit int64) io.Reader {
+	return &limitedBody{r: r, remaining: limit, limit: limit}
+}
+
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Only report the limit when there is more to read
+		var one [1]byte
+		if n, err := b.r.Read(one[:]); n == 0 {
+			return 0, err
+		}
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// errPayloadCut signals that the body ended at the size limit; containers
+// decoded so far are kept and closed with a marker.
+var errPayloadCut = errors.New("payload cut at size limit")
+
+// limitedDecoder builds a JSON value token by token, enforcing depth and
+// member limits without rejecting the payload: anything past a limit is
+// replaced with a marker.
+type limitedDecoder struct {
+	dec       *json.Decoder
+	limits    PayloadLimits
+	keys      int
+	truncated bool
+}
+
+// decodeLimitedObject decodes a single JSON object from r under limits. It
+// reports whether anything was truncated.
+func decodeLimitedObject(r io.Reader, limits PayloadLimits) (map[string]interface{}, bool, error) {
+	d := &limitedDecoder{dec: json.NewDecoder(r), limits: limits}
+	d.dec.UseNumber()
+
+	tok, err := d.token()
+	if err == errPayloadCut {
+		return nil, true, &PayloadError{Message: "payload exceeds the size limit before any data"}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, false, &PayloadError{Message: "payload must be a JSON object"}
+	}
+
+	obj, err := d.object(1)
+	if err == errPayloadCut {
+		return obj, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if d.dec.More() {
+		return nil, false, &PayloadError{Message: "unexpected data after JSON value"}
+	}
+	if _, err := d.dec.Token(); err != nil && err != io.EOF {
+		if isMaxBytesError(err) {
+			return obj, true, nil
+		}
+		return nil, false, &PayloadError{Message: "unexpected data after JSON value"}
+	}
+	return obj, d.truncated, nil
+}
+
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// token reads the next token, mapping the size limit to errPayloadCut and
+// other failures to a *PayloadError.
+func (d *limitedDecoder) token() (json.Token, error) {
+	tok, err := d.dec.Token()
+	if err == nil {
+		return tok, nil
+	}
+	if isMaxBytesError(err) {
+		d.truncated = true
+		return nil, errPayloadCut
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, &PayloadError{Message: fmt.Sprintf("invalid JSON: %v", err)}
+}
+
+// value decodes the value starting with tok at depth.
+func (d *limitedDecoder) value(tok json.Token, depth int) (interface{}, error) {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		if depth > d.limits.MaxDepth {
+			return d.skipContainer()
+		}
+		return d.object(depth)
+	case '[':
+		if depth > d.limits.MaxDepth {
+			return d.skipContainer()
+		}
+		return d.array(depth)
+	}
+	return nil, &PayloadError{Message: fmt.Sprintf("invalid JSON: unexpected '%s'", delim)}
+}
+
+// object decodes members up to the closing brace. When the body is cut,
+// the members read so far are returned along with errPayloadCut.
+func (d *limitedDecoder) object(depth int) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	omitted := 0
+	finish := func(err error) (map[string]interface{}, error) {
+		if omitted > 0 {
+			obj[truncatedKey] = fmt.Sprintf("%d keys omitted (limit %d)", omitted, d.limits.MaxKeys)
+		}
+		if err == errPayloadCut {
+			obj[truncatedKey] = d.cutMarker()
+		}
+		return obj, err
+	}
+
+	for {
+		tok, err := d.token()
+		if err != nil {
+			if err == errPayloadCut {
+				return finish(err)
+			}
+			return nil, err
+		}
+		if delim, ok := tok.(json.Delim); ok && delim == '}' {
+			return finish(nil)
+		}
+		key, _ := tok.(string)
+
+		if tok, err = d.token(); err != nil {
+			if err == errPayloadCut {
+				return finish(err)
+			}
+			return nil, err
+		}
+		d.keys++
+		if d.keys > d.limits.MaxKeys {
+			omitted++
+			d.truncated = true
+			// Consume the value without keeping it
+			if _, err := d.value(tok, d.limits.MaxDepth+1); err != nil {
+				if err == errPayloadCut {
+					return finish(err)
+				}
+				return nil, err
+			}
+			continue
+		}
+
+		v, err := d.value(tok, depth+1)
+		if err != nil && err != errPayloadCut {
+			return nil, err
+		}
+		obj[key] = v
+		if err == errPayloadCut {
+			return finish(err)
+		}
+	}
+}
+
+// array decodes elements up to the closing bracket. When the body is cut,
+// the elements read so far are returned along with errPayloadCut.
+func (d *limitedDecoder) array(depth int) ([]interface{}, error) {
+	arr := make([]interface{}, 0)
+	omitted := 0
+	finish := func(err error) ([]interface{}, error) {
+		if omitted > 0 {
+			arr = append(arr, fmt.Sprintf("%s: %d items omitted (limit %d)", truncatedKey, omitted, d.limits.MaxKeys))
+		}
+		if err == errPayloadCut {
+			arr = append(arr, truncatedKey+": "+d.cutMarker())
+		}
+		return arr, err
+	}
+
+	for {
+		tok, err := d.token()
+		if err != nil {
+			if err == errPayloadCut {
+				return finish(err)
+			}
+			return nil, err
+		}
+		if delim, ok := tok.(json.Delim); ok && delim == ']' {
+			return finish(nil)
+		}
+
+		d.keys++
+		if d.keys > d.limits.MaxKeys {
+			omitted++
+			d.truncated = true
+			if _, err := d.value(tok, d.limits.MaxDepth+1); err != nil {
+				if err == errPayloadCut {
+					return finish(err)
+				}
+				return nil, err
+			}
+			continue
+		}
+
+		v, err := d.value(tok, depth+1)
+		if err != nil && err != errPayloadCut {
+			return nil, err
+		}
+		arr = append(arr, v)
+		if err == errPayloadCut {
+			return finish(err)
+		}
+	}
+}
+
+// skipContainer consumes a container nested deeper than MaxDepth (its
+// opening delimiter already read) and returns a marker in its place.
+func (d *limitedDecoder) skipContainer() (interface{}, error) {
+	d.truncated = true
+	marker := fmt.Sprintf("%s: nesting deeper than %d", truncatedKey, d.limits.MaxDepth)
+	for open := 1; open > 0; {
+		tok, err := d.token()
+		if err != nil {
+			return marker, err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				open++
+			case '}', ']':
+				open--
+			}
+		}
+	}
+	return marker, nil
+}
+
+// cutMarker describes a body cut at the size limit.
+func (d *limitedDecoder) cutMarker() string {
+	return fmt.Sprintf("body exceeds %d bytes", d.limits.MaxBytes)
+}
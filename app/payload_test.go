@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressBody encodes body with the given Content-Encoding
+func compressBody(t *testing.T, encoding string, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	switch encoding {
+	case "gzip":
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(body))
+		zw.Close()
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("zstd.NewWriter failed: %v", err)
+		}
+		zw.Write([]byte(body))
+		zw.Close()
+	default:
+		buf.WriteString(body)
+	}
+	return buf.Bytes()
+}
+
+// TestDecodedBody tests gzip, zstd and unsupported request encodings
+func TestDecodedBody(t *testing.T) {
+	payload := `{"context": {"user": "ana"}, "label": "compressed"}`
+
+	for _, encoding := range []string{"", "gzip", "zstd"} {
+		t.Run("encoding "+encoding, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/data", bytes.NewReader(compressBody(t, encoding, payload)))
+			r.Header.Set("Content-Encoding", encoding)
+
+			body, err := decodedBody(r, defaultPayloadLimits().MaxBytes)
+			if err != nil {
+				t.Fatalf("decodedBody() failed: %v", err)
+			}
+			msg, err := ParseDumpMessageLimited(body, defaultPayloadLimits())
+			if err != nil {
+				t.Fatalf("ParseDumpMessageLimited() failed: %v", err)
+			}
+			if msg.Label != "compressed" || msg.Truncated {
+				t.Errorf("Unexpected message: %+v", msg)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/data", strings.NewReader(payload))
+	r.Header.Set("Content-Encoding", "br")
+	if _, err := decodedBody(r, defaultPayloadLimits().MaxBytes); !errors.Is(err, errUnsupportedEncoding) {
+		t.Errorf("Expected errUnsupportedEncoding, got %v", err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/data", strings.NewReader(payload))
+	r.Header.Set("Content-Encoding", "gzip")
+	if _, err := decodedBody(r, defaultPayloadLimits().MaxBytes); err == nil {
+		t.Error("A plain body labelled gzip should be rejected")
+	}
+}
+
+// TestDecodedBody_ZstdWindow tests that a zstd frame cannot claim more memory than the body limit allows
+func TestDecodedBody_ZstdWindow(t *testing.T) {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf, zstd.WithWindowSize(64<<20))
+	if err != nil {
+		t.Fatalf("zstd.NewWriter failed: %v", err)
+	}
+	io.Copy(zw, strings.NewReader(`{"context": "`+strings.Repeat("a", 1<<20)+`"}`))
+	zw.Close()
+
+	for _, tc := range []struct {
+		maxBytes int64
+		ok       bool
+	}{
+		{1 << 20, false},
+		{128 << 20, true},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/data", bytes.NewReader(buf.Bytes()))
+		r.Header.Set("Content-Encoding", "zstd")
+		body, err := decodedBody(r, tc.maxBytes)
+		if err != nil {
+			t.Fatalf("decodedBody() failed: %v", err)
+		}
+		_, err = io.ReadAll(body)
+		body.Close()
+		if (err == nil) != tc.ok {
+			t.Errorf("Limit %d: expected ok=%v, got %v", tc.maxBytes, tc.ok, err)
+		}
+	}
+}
+
+// TestLimitBody tests that a compressed body cannot expand past the limit
+func TestLimitBody(t *testing.T) {
+	bomb := compressBody(t, "gzip", strings.Repeat("a", 1<<20))
+	zr, _ := gzip.NewReader(bytes.NewReader(bomb))
+
+	_, err := io.ReadAll(limitBody(zr, 1024))
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) || maxBytesErr.Limit != 1024 {
+		t.Errorf("Expected a MaxBytesError for 1024 bytes, got %v", err)
+	}
+
+	data, err := io.ReadAll(limitBody(strings.NewReader("exact"), 5))
+	if err != nil || string(data) != "exact" {
+		t.Errorf("A body at the limit should be read whole, got %q, %v", data, err)
+	}
+}
+
+// TestParseDumpMessageLimited_Depth tests that deep values are replaced with a marker
+func TestParseDumpMessageLimited_Depth(t *testing.T) {
+	limits := defaultPayloadLimits()
+	limits.MaxDepth = 3
+
+	msg, err := ParseDumpMessageLimited(strings.NewReader(`{"context": {"a": {"b": {"c": 1}}, "ok": [1, 2]}}`), limits)
+	if err != nil {
+		t.Fatalf("ParseDumpMessageLimited() failed: %v", err)
+	}
+	if !msg.Truncated {
+		t.Error("Message should be flagged as truncated")
+	}
+
+	ctx := msg.Context.(map[string]interface{})
+	inner := ctx["a"].(map[string]interface{})
+	if marker, ok := inner["b"].(string); !ok || !strings.HasPrefix(marker, truncatedKey) {
+		t.Errorf("Expected a depth marker, got %#v", inner["b"])
+	}
+	if fmt.Sprint(ctx["ok"]) != "[1 2]" {
+		t.Errorf("Values within the limit should be kept, got %v", ctx["ok"])
+	}
+}
+
+// TestParseDumpMessageLimited_Keys tests that members past the key limit are omitted
+func TestParseDumpMessageLimited_Keys(t *testing.T) {
+	limits := defaultPayloadLimits()
+	limits.MaxKeys = 3
+
+	msg, err := ParseDumpMessageLimited(strings.NewReader(`{"label": "x", "context": {"a": 1, "b": 2, "c": 3}, "color": "red"}`), limits)
+	if err != nil {
+		t.Fatalf("ParseDumpMessageLimited() failed: %v", err)
+	}
+	if !msg.Truncated || msg.Label != "x" || msg.Color != "" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+	ctx := msg.Context.(map[string]interface{})
+	if ctx["a"] == nil || ctx["b"] != nil || ctx[truncatedKey] != "2 keys omitted (limit 3)" {
+		t.Errorf("Unexpected context: %v", ctx)
+	}
+
+	limits.MaxKeys = 4
+	items, _ := ParseDumpMessageLimited(strings.NewReader(`{"context": [1, 2, 3, 4, 5]}`), limits)
+	list := items.Context.([]interface{})
+	if len(list) != 4 || !strings.Contains(fmt.Sprint(list[3]), "2 items omitted") {
+		t.Errorf("Unexpected array: %v", list)
+	}
+}
+
+// TestParseDumpMessageLimited_Size tests that a body cut at the size limit is kept
+func TestParseDumpMessageLimited_Size(t *testing.T) {
+	limits := defaultPayloadLimits()
+	limits.MaxBytes = 40
+
+	body := `{"label": "big", "context": ["first", "second", "` + strings.Repeat("x", 100) + `"]}`
+	msg, err := ParseDumpMessageLimited(strings.NewReader(body), limits)
+	if err != nil {
+		t.Fatalf("ParseDumpMessageLimited() failed: %v", err)
+	}
+	if !msg.Truncated || msg.Label != "big" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+	list := msg.Context.([]interface{})
+	if list[0] != "first" || !strings.Contains(fmt.Sprint(list[len(list)-1]), "body exceeds 40 bytes") {
+		t.Errorf("Unexpected context: %v", list)
+	}
+
+	if _, err := ParseDumpMessageLimited(strings.NewReader(`{"context": [1, 2`), defaultPayloadLimits()); err == nil {
+		t.Error("A body that ends early without hitting the limit should be rejected")
+	}
+}
+
+// TestProfilePayloadLimits tests profile overrides of the defaults
+func TestProfilePayloadLimits(t *testing.T) {
+	if limits := profilePayloadLimits(Profile{}); limits != defaultPayloadLimits() {
+		t.Errorf("Expected defaults, got %+v", limits)
+	}
+	limits := profilePayloadLimits(Profile{MaxBodyMB: 2, MaxJSONDepth: 8, MaxJSONKeys: 50})
+	if limits.MaxBytes != 2<<20 || limits.MaxDepth != 8 || limits.MaxKeys != 50 {
+		t.Errorf("Unexpected limits: %+v", limits)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DumpQuery filters retained dumps. Empty fields do not filter.
+type DumpQuery struct {
+	Profile  string    `json:"profile,omitempty"` // exact profile that received the dump
+	Text     string    `json:"text,omitempty"`    // case-insensitive substring of the whole message
+	Label    string    `json:"label,omitempty"`   // exact label (case-insensitive)
+	Service  string    `json:"service,omitempty"` // exact service (case-insensitive)
+	Request  string    `json:"request,omitempty"` // exact request correlation ID
+	Color    string    `json:"color,omitempty"`   // exact color or semantic type, e.g. "error"
+	File     string    `json:"file,omitempty"`    // substring of frame.file
+	Since    time.Time `json:"since,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+	Where    []string  `json:"where,omitempty"` // JSON path predicates, e.g. "context.user.id == 42"
+	Page     int       `json:"page,omitempty"`
+	PageSize int       `json:"page_size,omitempty"`
+}
+
+// Where a query searched: the stored history of a profile that keeps one, or
+// the last defaultDumpRetention dumps received this session.
+const (
+	QuerySourceHistory = "history"
+	QuerySourceSession = "session"
+)
+
+// DumpQueryResult is one page of matching dumps, newest first.
+type DumpQueryResult struct {
+	Messages []*DumpMessage `json:"messages"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Source   string         `json:"source,omitempty"` // QuerySourceHistory or QuerySourceSession
+}
+
+// dumpPredicate is a parsed "path op value" expression.
+type dumpPredicate struct {
+	path  []string
+	op    string
+	value interface{}
+}
+
+var predicatePattern = regexp.MustCompile(`^\s*([^\s=!<>~]+)\s*(==|!=|>=|<=|~=|>|<)\s*(.*?)\s*$`)
+
+// parsePredicate parses expressions such as `context.user.id == 42`,
+// `frame.line >= 10` or `label ~= "checkout"`. The value is read as a JSON
+// literal when possible and as a bare string otherwise.
+func parsePredicate(expr string) (*dumpPredicate, error) {
+	m := predicatePattern.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid predicate %q, expected 'path op value'", expr)
+	}
+
+	path := strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(m[1]), ".")
+	for _, segment := range path {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid path %q", m[1])
+		}
+	}
+
+	var value interface{} = m[3]
+	decoder := json.NewDecoder(strings.NewReader(m[3]))
+	decoder.UseNumber()
+	var literal interface{}
+	if err := decoder.Decode(&literal); err == nil && !decoder.More() {
+		value = literal
+	}
+
+	if (m[2] == ">" || m[2] == ">=" || m[2] == "<" || m[2] == "<=") && value == nil {
+		return nil, fmt.Errorf("operator %s cannot compare with null", m[2])
+	}
+
+	return &dumpPredicate{path: path, op: m[2], value: value}, nil
+}
+
+// match evaluates the predicate against a decoded message document.
+func (p *dumpPredicate) match(doc interface{}) bool {
+	actual, found := lookupPath(doc, p.path)
+	if !found {
+		return p.op == "!="
+	}
+
+	switch p.op {
+	case "==":
+		return valuesEqual(actual, p.value)
+	case "!=":
+		return !valuesEqual(actual, p.value)
+	case "~=":
+		return strings.Contains(strings.ToLower(scalarString(actual)), strings.ToLower(scalarString(p.value)))
+	}
+
+	cmp, ok := compareValues(actual, p.value)
+	if !ok {
+		return false
+	}
+	switch p.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// lookupPath walks a decoded JSON document. Numeric segments index arrays.
+func lookupPath(doc interface{}, path []string) (interface{}, bool) {
+	current := doc
+	for _, segment := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func scalarString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	case bool:
+		return strconv.FormatBool(s)
+	}
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return fa == fb
+		}
+	}
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return scalarString(a) == scalarString(b)
+}
+
+// compareValues orders two numbers, or two strings lexicographically.
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if !okA || !okB {
+		return 0, false
+	}
+	return strings.Compare(sa, sb), true
+}
+
+// RunDumpQuery filters messages (oldest first) and returns the requested page,
+// newest first.
+func RunDumpQuery(messages []*DumpMessage, q DumpQuery) (*DumpQueryResult, error) {
+	predicates := make([]*dumpPredicate, 0, len(q.Where))
+	for _, expr := range q.Where {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		p, err := parsePredicate(expr)
+		if err != nil {
+			return nil, &PayloadError{Field: "where", Message: err.Error()}
+		}
+		predicates = append(predicates, p)
+	}
+
+	page, pageSize := q.Page, q.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if page > maxHistoryPage {
+		page = maxHistoryPage
+	}
+	if pageSize <= 0 {
+		pageSize = defaultHistoryPageSize
+	}
+	if pageSize > maxHistoryPageSize {
+		pageSize = maxHistoryPageSize
+	}
+
+	text := strings.ToLower(q.Text)
+	file := strings.ToLower(q.File)
+
+	matches := []*DumpMessage{}
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+
+		if q.Profile != "" && msg.Profile != q.Profile {
+			continue
+		}
+		if q.Request != "" && msg.RequestID != q.Request {
+			continue
+		}
+		if q.Service != "" && !strings.EqualFold(msg.Service, q.Service) {
+			continue
+		}
+		if q.Label != "" && !strings.EqualFold(msg.Label, q.Label) {
+			continue
+		}
+		if q.Color != "" && !strings.EqualFold(msg.Color, q.Color) {
+			continue
+		}
+		if file != "" && (msg.Frame == nil || !strings.Contains(strings.ToLower(msg.Frame.File), file)) {
+			continue
+		}
+		if !q.Since.IsZero() && msg.ReceivedAt.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && msg.ReceivedAt.After(q.Until) {
+			continue
+		}
+
+		if text == "" && len(predicates) == 0 {
+			matches = append(matches, msg)
+			continue
+		}
+
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(string(encoded)), text) {
+			continue
+		}
+		if len(predicates) > 0 {
+			var doc interface{}
+			decoder := json.NewDecoder(bytes.NewReader(encoded))
+			decoder.UseNumber()
+			if err := decoder.Decode(&doc); err != nil {
+				continue
+			}
+			matched := true
+			for _, p := range predicates {
+				if !p.match(doc) {
+					matched = false
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		matches = append(matches, msg)
+	}
+
+	result := &DumpQueryResult{Messages: []*DumpMessage{}, Total: len(matches), Page: page, PageSize: pageSize}
+	start := (page - 1) * pageSize
+	if start < len(matches) {
+		end := start + pageSize
+		if end > len(matches) {
+			end = len(matches)
+		}
+		result.Messages = matches[start:end]
+	}
+	return result, nil
+}
+
+// parseDumpQuery reads a DumpQuery from URL parameters:
+// q, label, color, file, since, until, where (repeatable), page, page_size.
+// Times are RFC 3339 or Unix milliseconds.
+func parseDumpQuery(values url.Values) (DumpQuery, error) {
+	q := DumpQuery{
+		Text:    values.Get("q"),
+		Label:   values.Get("label"),
+		Service: values.Get("service"),
+		Request: values.Get("request"),
+		Color:   values.Get("color"),
+		File:    values.Get("file"),
+		Where:   values["where"],
+	}
+
+	var err error
+	if q.Since, err = parseQueryTime(values.Get("since")); err != nil {
+		return q, &PayloadError{Field: "since", Message: err.Error()}
+	}
+	if q.Until, err = parseQueryTime(values.Get("until")); err != nil {
+		return q, &PayloadError{Field: "until", Message: err.Error()}
+	}
+	if v := values.Get("page"); v != "" {
+		if q.Page, err = strconv.Atoi(v); err != nil {
+			return q, &PayloadError{Field: "page", Message: "must be an integer"}
+		}
+	}
+	if v := values.Get("page_size"); v != "" {
+		if q.PageSize, err = strconv.Atoi(v); err != nil {
+			return q, &PayloadError{Field: "page_size", Message: "must be an integer"}
+		}
+	}
+	return q, nil
+}
+
+func parseQueryTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be RFC 3339 or Unix milliseconds")
+	}
+	return t, nil
+}
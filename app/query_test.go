@@ -0,0 +1,149 @@
+package main
+
+import (
+	"math"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// buildQueryMessages parses payloads into retained messages, oldest first
+func buildQueryMessages(t *testing.T, payloads ...string) []*DumpMessage {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	messages := make([]*DumpMessage, 0, len(payloads))
+	for i, p := range payloads {
+		msg, err := ParseDumpMessage([]byte(p))
+		if err != nil {
+			t.Fatalf("ParseDumpMessage(%s) failed: %v", p, err)
+		}
+		msg.ReceivedAt = base.Add(time.Duration(i) * time.Minute)
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// TestRunDumpQuery tests structured filters and JSON path predicates
+func TestRunDumpQuery(t *testing.T) {
+	messages := buildQueryMessages(t,
+		`{"context": {"user": {"id": 42, "name": "Ana"}}, "label": "user", "color": "success", "frame": {"file": "/app/User.php", "line": 10}}`,
+		`{"context": {"user": {"id": 7, "name": "Luis"}}, "label": "user", "color": "error", "frame": {"file": "/app/User.php", "line": 20}}`,
+		`{"context": {"total": 1500.5, "items": [{"sku": "A1"}]}, "label": "order", "color": "info", "frame": {"file": "/app/Order.php", "line": 5}}`,
+	)
+
+	testCases := []struct {
+		name     string
+		query    DumpQuery
+		expected []string // expected labels, newest first
+	}{
+		{name: "No filters", query: DumpQuery{}, expected: []string{"order", "user", "user"}},
+		{name: "Label", query: DumpQuery{Label: "USER"}, expected: []string{"user", "user"}},
+		{name: "Color", query: DumpQuery{Color: "error"}, expected: []string{"user"}},
+		{name: "File", query: DumpQuery{File: "order.php"}, expected: []string{"order"}},
+		{name: "Text", query: DumpQuery{Text: "luis"}, expected: []string{"user"}},
+		{name: "Equal number", query: DumpQuery{Where: []string{"context.user.id == 42"}}, expected: []string{"user"}},
+		{name: "Not equal", query: DumpQuery{Where: []string{"context.user.id != 42"}}, expected: []string{"order", "user"}},
+		{name: "Greater than", query: DumpQuery{Where: []string{"context.total > 1000"}}, expected: []string{"order"}},
+		{name: "Array index", query: DumpQuery{Where: []string{"context.items[0].sku == A1"}}, expected: []string{"order"}},
+		{name: "Quoted string", query: DumpQuery{Where: []string{`context.user.name == "Ana"`}}, expected: []string{"user"}},
+		{name: "Contains", query: DumpQuery{Where: []string{"frame.file ~= user"}}, expected: []string{"user", "user"}},
+		{name: "Combined", query: DumpQuery{Label: "user", Where: []string{"frame.line >= 15"}}, expected: []string{"user"}},
+		{name: "Since", query: DumpQuery{Since: messages[2].ReceivedAt}, expected: []string{"order"}},
+		{name: "Until", query: DumpQuery{Until: messages[0].ReceivedAt}, expected: []string{"user"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := RunDumpQuery(messages, tc.query)
+			if err != nil {
+				t.Fatalf("RunDumpQuery() failed: %v", err)
+			}
+			if result.Total != len(tc.expected) {
+				t.Fatalf("Expected %d matches, got %d", len(tc.expected), result.Total)
+			}
+			for i, label := range tc.expected {
+				if result.Messages[i].Label != label {
+					t.Errorf("Match %d: expected label '%s', got '%s'", i, label, result.Messages[i].Label)
+				}
+			}
+		})
+	}
+}
+
+// TestRunDumpQuery_Paging tests that results are paged newest first
+func TestRunDumpQuery_Paging(t *testing.T) {
+	payloads := make([]string, 5)
+	for i := range payloads {
+		payloads[i] = `{"context": 1}`
+	}
+	messages := buildQueryMessages(t, payloads...)
+
+	result, err := RunDumpQuery(messages, DumpQuery{Page: 2, PageSize: 2})
+	if err != nil {
+		t.Fatalf("RunDumpQuery() failed: %v", err)
+	}
+	if result.Total != 5 || len(result.Messages) != 2 {
+		t.Fatalf("Expected 2 of 5 messages, got %d of %d", len(result.Messages), result.Total)
+	}
+	if result.Messages[0].ID != messages[2].ID {
+		t.Errorf("Expected page 2 to start at the third newest message")
+	}
+
+	result, _ = RunDumpQuery(messages, DumpQuery{Page: 4, PageSize: 2})
+	if len(result.Messages) != 0 {
+		t.Errorf("Expected an empty page past the end, got %d", len(result.Messages))
+	}
+
+	result, err = RunDumpQuery(messages, DumpQuery{Page: math.MaxInt, PageSize: maxHistoryPageSize})
+	if err != nil {
+		t.Fatalf("RunDumpQuery() failed: %v", err)
+	}
+	if len(result.Messages) != 0 || result.Page != maxHistoryPage {
+		t.Errorf("Expected an empty page capped at %d, got %d messages on page %d", maxHistoryPage, len(result.Messages), result.Page)
+	}
+}
+
+// TestRunDumpQuery_Profile tests that a query only matches the dumps of its profile
+func TestRunDumpQuery_Profile(t *testing.T) {
+	messages := buildQueryMessages(t, `{"context": 1}`, `{"context": 2}`, `{"context": 3}`)
+	messages[0].Profile = "a"
+	messages[1].Profile = "b"
+	messages[2].Profile = "a"
+
+	result, err := RunDumpQuery(messages, DumpQuery{Profile: "a"})
+	if err != nil {
+		t.Fatalf("RunDumpQuery() failed: %v", err)
+	}
+	if result.Total != 2 || result.Messages[0].ID != messages[2].ID || result.Messages[1].ID != messages[0].ID {
+		t.Errorf("Expected only the dumps of profile a, got %d", result.Total)
+	}
+}
+
+// TestParsePredicate_Invalid tests rejecting malformed predicates
+func TestParsePredicate_Invalid(t *testing.T) {
+	for _, expr := range []string{"context.user.id", "== 42", "context..id == 1", "frame.line > null"} {
+		if _, err := parsePredicate(expr); err == nil {
+			t.Errorf("parsePredicate(%q) should fail", expr)
+		}
+	}
+}
+
+// TestParseDumpQuery tests reading a query from URL parameters
+func TestParseDumpQuery(t *testing.T) {
+	values, _ := url.ParseQuery("label=user&where=context.user.id%3D%3D42&where=frame.line>1&since=1700000000000&page=2&page_size=10")
+	q, err := parseDumpQuery(values)
+	if err != nil {
+		t.Fatalf("parseDumpQuery() failed: %v", err)
+	}
+	if q.Label != "user" || len(q.Where) != 2 || q.Page != 2 || q.PageSize != 10 {
+		t.Errorf("Unexpected query: %+v", q)
+	}
+	if !q.Since.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("Unexpected since: %v", q.Since)
+	}
+
+	values, _ = url.ParseQuery("until=yesterday")
+	if _, err := parseDumpQuery(values); err == nil {
+		t.Error("parseDumpQuery() should reject an invalid time")
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTrackedRequests bounds the request records kept in memory.
+const maxTrackedRequests = 500
+
+// maxRequestID bounds correlation IDs taken from clients.
+const maxRequestID = 128
+
+// Request marker events accepted by /request.
+const (
+	RequestStart = "start"
+	RequestEnd   = "end"
+)
+
+// severityRank orders dump colors and semantic types by severity.
+var severityRank = map[string]int{
+	"debug":   1,
+	"gray":    1,
+	"success": 2,
+	"green":   2,
+	"info":    3,
+	"blue":    3,
+	"warning": 4,
+	"yellow":  4,
+	"orange":  4,
+	"error":   5,
+	"danger":  5,
+	"red":     5,
+}
+
+// severityNames maps a rank back to the name reported on RequestRecord.
+var severityNames = []string{"", "debug", "success", "info", "warning", "error"}
+
+// RequestRecord groups the dumps that share a correlation ID, i.e. that
+// were sent while handling one request of the client application.
+type RequestRecord struct {
+	ID         string    `json:"id"`
+	Profile    string    `json:"profile,omitempty"` // profile whose listener received it
+	Method     string    `json:"method,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	Status     int       `json:"status,omitempty"`
+	Service    string    `json:"service,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	Ended      bool      `json:"ended"`       // an end marker was received
+	DurationMs float64   `json:"duration_ms"` // to the end marker, or to the latest dump while open
+	DumpCount  int       `json:"dump_count"`
+	Severity   string    `json:"severity,omitempty"` // highest severity among the dumps
+}
+
+// RequestMarker is a start or end event posted to /request.
+type RequestMarker struct {
+	ID      string    `json:"id"`
+	Profile string    `json:"profile,omitempty"` // set by the listener, not read from the body
+	Event   string    `json:"event"`
+	Method  string    `json:"method,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Status  int       `json:"status,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+type requestEntry struct {
+	record   RequestRecord
+	severity int
+	lastAt   time.Time
+}
+
+// requestKey identifies a request: the same correlation ID sent to two
+// profiles makes two records.
+type requestKey struct {
+	profile string
+	id      string
+}
+
+// RequestTracker groups dumps into request records by profile and
+// correlation ID, keeping the most recent maxTrackedRequests.
+type RequestTracker struct {
+	mu      sync.Mutex
+	entries map[requestKey]*requestEntry
+	order   []requestKey // oldest first, for eviction
+}
+
+// NewRequestTracker creates an empty tracker.
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{entries: make(map[requestKey]*requestEntry)}
+}
+
+// normalizeRequestID trims a correlation ID and bounds its length.
+func normalizeRequestID(id string) string {
+	return truncateBytes(strings.TrimSpace(id), maxRequestID)
+}
+
+// requestCorrelationID returns the correlation ID carried by the request
+// headers: the trace ID of a W3C traceparent, else X-Request-Id.
+func requestCorrelationID(r *http.Request) string {
+	if id := traceparentID(r.Header.Get("traceparent")); id != "" {
+		return id
+	}
+	return normalizeRequestID(r.Header.Get("X-Request-Id"))
+}
+
+// traceparentID extracts the trace ID of a W3C traceparent header
+// ("00-<32 hex trace id>-<16 hex parent id>-<2 hex flags>").
+func traceparentID(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+	for _, c := range traceID {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return ""
+		}
+	}
+	return traceID
+}
+
+// entryLocked returns the entry of id in profile, creating it at start if
+// needed.
+func (t *RequestTracker) entryLocked(profile, id string, start time.Time) *requestEntry {
+	key := requestKey{profile: profile, id: id}
+	entry, ok := t.entries[key]
+	if ok {
+		return entry
+	}
+	if len(t.order) >= maxTrackedRequests {
+		delete(t.entries, t.order[0])
+		copy(t.order, t.order[1:])
+		t.order = t.order[:len(t.order)-1]
+	}
+	entry = &requestEntry{record: RequestRecord{ID: id, Profile: profile, StartedAt: start}, lastAt: start}
+	t.entries[key] = entry
+	t.order = append(t.order, key)
+	return entry
+}
+
+// AddDump counts msg in the record of its request and returns the updated
+// record, or nil when msg carries no request ID.
+func (t *RequestTracker) AddDump(msg *DumpMessage) *RequestRecord {
+	if msg.RequestID == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entryLocked(msg.Profile, msg.RequestID, msg.ReceivedAt)
+	entry.record.DumpCount++
+	if entry.record.Service == "" {
+		entry.record.Service = msg.Service
+	}
+	if rank := severityRank[strings.ToLower(msg.Color)]; rank > entry.severity {
+		entry.severity = rank
+		entry.record.Severity = severityNames[rank]
+	}
+	if msg.ReceivedAt.Before(entry.record.StartedAt) {
+		entry.record.StartedAt = msg.ReceivedAt
+	}
+	if msg.ReceivedAt.After(entry.lastAt) {
+		entry.lastAt = msg.ReceivedAt
+	}
+	if !entry.record.Ended {
+		entry.record.DurationMs = durationMs(entry.lastAt.Sub(entry.record.StartedAt))
+	}
+
+	record := entry.record
+	return &record
+}
+
+// Mark applies a start or end marker and returns the updated record.
+func (t *RequestTracker) Mark(m RequestMarker) (*RequestRecord, error) {
+	if m.ID == "" {
+		return nil, &PayloadError{Field: "id", Message: "is required"}
+	}
+	if m.Event != RequestStart && m.Event != RequestEnd {
+		return nil, &PayloadError{Field: "event", Message: "must be 'start' or 'end'"}
+	}
+	if m.At.IsZero() {
+		m.At = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entryLocked(m.Profile, m.ID, m.At)
+	if m.Method != "" {
+		entry.record.Method = m.Method
+	}
+	if m.URL != "" {
+		entry.record.URL = m.URL
+	}
+	switch m.Event {
+	case RequestStart:
+		// Dumps may arrive before the start marker over separate connections
+		if m.At.Before(entry.record.StartedAt) || entry.record.DumpCount == 0 {
+			entry.record.StartedAt = m.At
+		}
+	case RequestEnd:
+		entry.record.Ended = true
+		entry.record.Status = m.Status
+		entry.record.DurationMs = durationMs(m.At.Sub(entry.record.StartedAt))
+	}
+
+	record := entry.record
+	return &record, nil
+}
+
+// List returns the tracked requests of profile, most recently started
+// first.
+func (t *RequestTracker) List(profile string) []RequestRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records := make([]RequestRecord, 0, len(t.entries))
+	for key, entry := range t.entries {
+		if key.profile == profile {
+			records = append(records, entry.record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].StartedAt.After(records[j].StartedAt) })
+	return records
+}
+
+// Get returns the record of id in profile, or nil.
+func (t *RequestTracker) Get(profile, id string) *RequestRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[requestKey{profile: profile, id: id}]
+	if !ok {
+		return nil
+	}
+	record := entry.record
+	return &record
+}
+
+// Reset discards every tracked request.
+func (t *RequestTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = make(map[requestKey]*requestEntry)
+	t.order = nil
+}
+
+// ParseRequestMarker reads a /request body: {"id", "event", "method",
+// "url", "status", "at"}, where "at" is an optional client timestamp in
+// seconds. A missing id is taken from the traceparent or X-Request-Id
+// header by the caller.
+func ParseRequestMarker(body []byte) (RequestMarker, error) {
+	obj, err := decodePayloadObject(body)
+	if err != nil {
+		return RequestMarker{}, err
+	}
+
+	var m RequestMarker
+	if m.ID, err = optionalString(obj, "id", "id"); err != nil {
+		return m, err
+	}
+	m.ID = normalizeRequestID(m.ID)
+	if m.Event, err = optionalString(obj, "event", "event"); err != nil {
+		return m, err
+	}
+	if m.Method, err = optionalString(obj, "method", "method"); err != nil {
+		return m, err
+	}
+	if m.URL, err = optionalString(obj, "url", "url"); err != nil {
+		return m, err
+	}
+	if m.Status, err = optionalInt(obj, "status", "status"); err != nil {
+		return m, err
+	}
+	if m.At, err = optionalUnixSeconds(obj, "at", "at"); err != nil {
+		return m, err
+	}
+	return m, nil
+}
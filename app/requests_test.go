@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRequestTracker tests grouping dumps and markers into request records
+func TestRequestTracker(t *testing.T) {
+	tracker := NewRequestTracker()
+	start := time.Now()
+
+	if tracker.AddDump(&DumpMessage{ReceivedAt: start}) != nil {
+		t.Error("Dumps without a request ID should not be tracked")
+	}
+
+	if _, err := tracker.Mark(RequestMarker{ID: "req-1", Event: RequestStart, Method: "GET", URL: "/users", At: start}); err != nil {
+		t.Fatalf("Mark(start) failed: %v", err)
+	}
+	tracker.AddDump(&DumpMessage{RequestID: "req-1", Color: "info", Service: "api", ReceivedAt: start.Add(10 * time.Millisecond)})
+	tracker.AddDump(&DumpMessage{RequestID: "req-1", Color: "red", ReceivedAt: start.Add(20 * time.Millisecond)})
+	open := tracker.AddDump(&DumpMessage{RequestID: "req-1", Color: "warning", ReceivedAt: start.Add(30 * time.Millisecond)})
+
+	if open.DumpCount != 3 || open.Severity != "error" || open.Service != "api" || open.Ended {
+		t.Errorf("Unexpected open record: %+v", open)
+	}
+	if open.DurationMs != 30 {
+		t.Errorf("Open request should last until its latest dump, got %vms", open.DurationMs)
+	}
+
+	ended, err := tracker.Mark(RequestMarker{ID: "req-1", Event: RequestEnd, Status: 500, At: start.Add(100 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("Mark(end) failed: %v", err)
+	}
+	if !ended.Ended || ended.Status != 500 || ended.DurationMs != 100 || ended.Method != "GET" || ended.URL != "/users" {
+		t.Errorf("Unexpected ended record: %+v", ended)
+	}
+
+	// A dump that beats its start marker still counts from the dump
+	tracker.AddDump(&DumpMessage{RequestID: "req-2", ReceivedAt: start.Add(time.Second)})
+	late, _ := tracker.Mark(RequestMarker{ID: "req-2", Event: RequestStart, At: start.Add(1100 * time.Millisecond)})
+	if !late.StartedAt.Equal(start.Add(time.Second)) {
+		t.Errorf("Start should not move past an earlier dump, got %v", late.StartedAt)
+	}
+
+	list := tracker.List("")
+	if len(list) != 2 || list[0].ID != "req-2" || list[1].ID != "req-1" {
+		t.Errorf("Expected newest first, got %+v", list)
+	}
+
+	if _, err := tracker.Mark(RequestMarker{ID: "req-3", Event: "pause"}); err == nil {
+		t.Error("Unknown marker events should be rejected")
+	}
+	if _, err := tracker.Mark(RequestMarker{Event: RequestStart}); err == nil {
+		t.Error("Markers without an ID should be rejected")
+	}
+}
+
+// TestRequestTracker_Eviction tests that only the most recent requests are kept
+func TestRequestTracker_Eviction(t *testing.T) {
+	tracker := NewRequestTracker()
+	for i := 0; i < maxTrackedRequests+5; i++ {
+		tracker.AddDump(&DumpMessage{RequestID: fmt.Sprintf("req-%d", i), ReceivedAt: time.Now()})
+	}
+	if n := len(tracker.List("")); n != maxTrackedRequests {
+		t.Errorf("Expected %d requests, got %d", maxTrackedRequests, n)
+	}
+	if tracker.Get("", "req-0") != nil || tracker.Get("", fmt.Sprintf("req-%d", maxTrackedRequests+4)) == nil {
+		t.Error("The oldest requests should be evicted first")
+	}
+}
+
+// TestRequestTracker_Profiles tests that the same request ID sent to two profiles makes two records
+func TestRequestTracker_Profiles(t *testing.T) {
+	tracker := NewRequestTracker()
+	start := time.Now()
+	tracker.AddDump(&DumpMessage{RequestID: "req-1", Profile: "a", ReceivedAt: start})
+	tracker.Mark(RequestMarker{ID: "req-1", Profile: "b", Event: RequestStart, URL: "/secret", At: start})
+
+	a := tracker.Get("a", "req-1")
+	if a == nil || a.Profile != "a" || a.DumpCount != 1 || a.URL != "" {
+		t.Errorf("Unexpected record of profile a: %+v", a)
+	}
+	if list := tracker.List("b"); len(list) != 1 || list[0].URL != "/secret" || list[0].DumpCount != 0 {
+		t.Errorf("Unexpected records of profile b: %+v", list)
+	}
+	if tracker.Get("c", "req-1") != nil {
+		t.Error("Records should not be visible to other profiles")
+	}
+}
+
+// TestRequestCorrelationID tests reading the ID from payloads and headers
+func TestRequestCorrelationID(t *testing.T) {
+	testCases := []struct {
+		name        string
+		traceparent string
+		requestID   string
+		expected    string
+	}{
+		{"traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "abc", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"invalid traceparent", "00-xyz-00f067aa0ba902b7-01", "abc", "abc"},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", ""},
+		{"x-request-id", "", " abc ", "abc"},
+		{"multi-byte cut", "", strings.Repeat("a", maxRequestID-1) + "é", strings.Repeat("a", maxRequestID-1)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/data", nil)
+			r.Header.Set("traceparent", tc.traceparent)
+			r.Header.Set("X-Request-Id", tc.requestID)
+			if id := requestCorrelationID(r); id != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, id)
+			}
+		})
+	}
+
+	msg, err := ParseDumpMessage([]byte(`{"context": 1, "trace_id": "t-1", "metadata": {"request_id": "r-1"}}`))
+	if err != nil || msg.RequestID != "r-1" {
+		t.Errorf("metadata.request_id should win, got %q, %v", msg.RequestID, err)
+	}
+	msg, _ = ParseDumpMessage([]byte(`{"context": 1, "trace_id": "t-1"}`))
+	if msg.RequestID != "t-1" {
+		t.Errorf("Expected trace_id fallback, got %q", msg.RequestID)
+	}
+}
+
+// TestParseRequestMarker tests reading /request bodies
+func TestParseRequestMarker(t *testing.T) {
+	m, err := ParseRequestMarker([]byte(`{"id": "req-1", "event": "end", "status": 404, "at": 1700000000.25}`))
+	if err != nil {
+		t.Fatalf("ParseRequestMarker() failed: %v", err)
+	}
+	if m.ID != "req-1" || m.Event != RequestEnd || m.Status != 404 || m.At.UnixMilli() != 1700000000250 {
+		t.Errorf("Unexpected marker: %+v", m)
+	}
+	if _, err := ParseRequestMarker([]byte(`{"id": "req-1", "at": -1}`)); err == nil {
+		t.Error("A negative timestamp should be rejected")
+	}
+}
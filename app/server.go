@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,23 +13,30 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
-// StartServer starts the HTTP server and returns the server instance for graceful shutdown.
-// The caller is responsible for managing the server lifecycle.
-func StartServer(ctx context.Context, host string, port int, app *App) *http.Server {
+// StartServer starts the HTTP server for profile, feeding accepted dumps into pipeline, and returns the
+// server instance for graceful shutdown. The caller is responsible for managing the server lifecycle.
+func StartServer(ctx context.Context, profile Profile, pipeline *profilePipeline, app *App) *http.Server {
+	host, port := profile.Server, profile.Port
 	runtime.LogInfof(ctx, "Attempting to start HTTP server...")
 	runtime.LogInfof(ctx, "Host: %s, Port: %d", host, port)
 
 	mux := http.NewServeMux()
 
-	// Health endpoint to report server status
+	// Health endpoint to report server status: version, profiles, uptime,
+	// listeners and message counts, so clients can check compatibility
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		runtime.LogInfof(ctx, "Health endpoint accessed from %s", r.RemoteAddr)
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
+		app.writeServerStatus(w, profile.Name, false)
+	})
+
+	// Status endpoint: the health report plus every running profile and the
+	// UI delivery counters
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		app.writeServerStatus(w, profile.Name, true)
 	})
 
 	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
@@ -36,52 +45,324 @@ func StartServer(ctx context.Context, host string, port int, app *App) *http.Ser
 			return
 		}
 
-		// Limit request body size to 10MB to prevent memory exhaustion
-		const maxBodySize = 10 * 1024 * 1024 // 10MB
-		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
-
-		body, err := io.ReadAll(r.Body)
+		// Bound the body before and after decompression; depth and key
+		// limits are enforced while decoding, truncating rather than rejecting
+		limits := profilePayloadLimits(profile)
+		r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBytes)
+		decoded, err := decodedBody(r, limits.MaxBytes)
 		if err != nil {
-			runtime.LogErrorf(ctx, "Error reading request body: %v", err)
-			http.Error(w, "Error reading request body", http.StatusInternalServerError)
+			runtime.LogErrorf(ctx, "Cannot decode request body: %v", err)
+			app.metrics.Inc(metricMessagesRejected, profile.Name, RejectEncoding)
+			writeEncodingError(w, err)
 			return
 		}
+		defer decoded.Close()
 
-		// DEBUG: Log del payload RAW recibido (truncate if too large)
-		debugPayload := string(body)
-		if len(debugPayload) > 1000 {
-			debugPayload = debugPayload[:1000] + "... (truncated)"
+		body := &countingReader{r: decoded}
+		msg, err := ParseDumpMessageLimited(body, limits)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Invalid payload received: %v", err)
+			app.metrics.Inc(metricMessagesRejected, profile.Name, RejectInvalid)
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+		app.metrics.Inc(metricMessagesReceived, profile.Name, "/data")
+		app.metrics.Observe(metricPayloadBytes, float64(body.n), "/data")
+		if msg.Truncated {
+			runtime.LogWarningf(ctx, "Dump %d exceeded the payload limits and was truncated", msg.ID)
+		}
+		if msg.Service == "" {
+			msg.Service = requestService(r)
 		}
-		runtime.LogInfof(ctx, "════════════════════════════════════════")
-		runtime.LogInfof(ctx, "RAW PAYLOAD RECEIVED:")
-		runtime.LogInfof(ctx, "%s", debugPayload)
-		runtime.LogInfof(ctx, "════════════════════════════════════════")
+		if msg.RequestID == "" {
+			msg.RequestID = requestCorrelationID(r)
+		}
+		app.enrichDump(pipeline, msg)
 
-		var js interface{}
-		if err := json.Unmarshal(body, &js); err != nil {
-			runtime.LogErrorf(ctx, "Invalid JSON received: %v", err)
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Error encoding message: %v", err)
+			http.Error(w, "Error encoding message", http.StatusInternalServerError)
 			return
 		}
 
 		// Don't increment counter here, let frontend handle it via UpdateVisibleCount
 		// This avoids double counting and ensures sync between frontend and backend
 
-		// Emit event to the frontend with the raw JSON string
-		runtime.EventsEmit(ctx, "newData", string(body))
+		// Retains and persists before queueing the canonical message for the
+		// frontend, so a crash right after cannot lose the dump
+		if !app.acceptDump(pipeline, msg, encoded) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Data received, not displayed"))
+			return
+		}
 		runtime.LogInfo(ctx, "Received and processed data successfully.")
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Data received successfully"))
 	})
 
+	// Batch endpoint: a JSON array or NDJSON stream of /data messages,
+	// validated one by one and delivered to the frontend as a single event
+	mux.HandleFunc("/data/batch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limits := profilePayloadLimits(profile)
+		r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBytes)
+		decoded, err := decodedBody(r, limits.MaxBytes)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Cannot decode batch body: %v", err)
+			app.metrics.Inc(metricMessagesRejected, profile.Name, RejectEncoding)
+			writeEncodingError(w, err)
+			return
+		}
+		defer decoded.Close()
+
+		body, err := io.ReadAll(limitBody(decoded, limits.MaxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				runtime.LogErrorf(ctx, "Batch body exceeds %d bytes", maxBytesErr.Limit)
+				app.metrics.Inc(metricMessagesRejected, profile.Name, RejectTooLarge)
+				writePayloadError(w, http.StatusRequestEntityTooLarge,
+					&PayloadError{Message: fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit)})
+				return
+			}
+			runtime.LogErrorf(ctx, "Error reading batch body: %v", err)
+			writePayloadError(w, http.StatusBadRequest, &PayloadError{Message: fmt.Sprintf("error reading request body: %v", err)})
+			return
+		}
+
+		messages, report, err := ParseDumpBatch(body, limits)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Invalid batch received: %v", err)
+			app.metrics.Inc(metricMessagesRejected, profile.Name, RejectInvalid)
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+		app.metrics.Add(metricMessagesReceived, float64(report.Accepted), profile.Name, "/data/batch")
+		app.metrics.Add(metricMessagesRejected, float64(report.Rejected), profile.Name, RejectInvalid)
+		app.metrics.Observe(metricPayloadBytes, float64(len(body)), "/data/batch")
+
+		delivered := 0
+		service, requestID := requestService(r), requestCorrelationID(r)
+		for _, msg := range messages {
+			if msg.Service == "" {
+				msg.Service = service
+			}
+			if msg.RequestID == "" {
+				msg.RequestID = requestID
+			}
+			app.enrichDump(pipeline, msg)
+			encoded, err := json.Marshal(msg)
+			if err != nil {
+				runtime.LogErrorf(ctx, "Error encoding message: %v", err)
+				continue
+			}
+			if app.acceptDump(pipeline, msg, encoded) {
+				delivered++
+			}
+		}
+		runtime.LogInfof(ctx, "Batch received: %d accepted, %d rejected, %d delivered", report.Accepted, report.Rejected, delivered)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+
+	// Checkpoint endpoint: the client blocks until the developer continues or
+	// aborts in the UI, the checkpoint times out, or the client disconnects.
+	mux.HandleFunc("/checkpoint", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 10*1024*1024))
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+		cp, err := ParseCheckpointRequest(body)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Invalid checkpoint received: %v", err)
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// The server-wide WriteTimeout would cut the long poll short.
+		if err := http.NewResponseController(w).SetWriteDeadline(cp.Deadline.Add(10 * time.Second)); err != nil {
+			runtime.LogErrorf(ctx, "Could not extend write deadline for checkpoint: %v", err)
+		}
+
+		decisionCh := app.checkpoints.Register(cp)
+		runtime.LogInfof(ctx, "Checkpoint %s waiting (label: %q, deadline: %s)", cp.ID, cp.Label, cp.Deadline.Format(time.RFC3339))
+		runtime.EventsEmit(ctx, "checkpoint", cp)
+
+		result := CheckpointResult{ID: cp.ID}
+		timer := time.NewTimer(time.Until(cp.Deadline))
+		defer timer.Stop()
+
+		select {
+		case result.Decision = <-decisionCh:
+		case <-timer.C:
+			if !app.checkpoints.Remove(cp.ID) {
+				// Resolved concurrently with the timeout; honour the decision.
+				result.Decision = <-decisionCh
+				break
+			}
+			result.Decision = cp.OnTimeout
+			result.TimedOut = true
+		case <-r.Context().Done():
+			app.checkpoints.Remove(cp.ID)
+			runtime.LogInfof(ctx, "Checkpoint %s client disconnected", cp.ID)
+			runtime.EventsEmit(ctx, "checkpointResolved", CheckpointResult{ID: cp.ID, Decision: CheckpointAbort})
+			return
+		case <-ctx.Done():
+			// Server is shutting down: let the client carry on.
+			app.checkpoints.Remove(cp.ID)
+			result.Decision = CheckpointContinue
+		}
+
+		runtime.LogInfof(ctx, "Checkpoint %s resolved: %s", cp.ID, result.Decision)
+		runtime.EventsEmit(ctx, "checkpointResolved", result)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+
+	// Timer endpoint: start/stop/lap events aggregated into benchmark records
+	mux.HandleFunc("/timer", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1024*1024))
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+		ev, err := ParseTimerEvent(body)
+		if err != nil {
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := app.timers.Record(ev)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Rejected timer event: %v", err)
+			writePayloadError(w, http.StatusConflict, err)
+			return
+		}
+
+		runtime.EventsEmit(ctx, "benchmark", record)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(record)
+	})
+
+	// Request markers: optional start/end events that bound the dumps
+	// sharing a correlation ID, e.g. from a Laravel middleware
+	mux.HandleFunc("/request", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1024*1024))
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return
+		}
+		marker, err := ParseRequestMarker(body)
+		if err != nil {
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+		if marker.ID == "" {
+			marker.ID = requestCorrelationID(r)
+		}
+		marker.Profile = pipeline.name
+		record, err := app.requests.Mark(marker)
+		if err != nil {
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		app.delivery.Push("requestUpdated", record)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(record)
+	})
+
+	// Query endpoint over the dumps this profile received, e.g.
+	// /query?label=user&where=context.user.id==42
+	mux.HandleFunc("/query", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query, err := parseDumpQuery(r.URL.Query())
+		if err != nil {
+			writePayloadError(w, http.StatusBadRequest, err)
+			return
+		}
+		query.Profile = pipeline.name
+		result, err := app.queryDumps(query)
+		if err != nil {
+			var payloadErr *PayloadError
+			if errors.As(err, &payloadErr) {
+				writePayloadError(w, http.StatusBadRequest, payloadErr)
+				return
+			}
+			http.Error(w, "Error querying dumps", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+
+	// Metrics about the visualizer itself in the Prometheus text format
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		app.writeMetrics(w)
+	})
+
+	// Live share: a read-only viewer page and its WebSocket stream, both
+	// protected by the token from App.StartShareSession
+	mux.HandleFunc("/share", app.share.ServeViewer)
+	mux.HandleFunc("/share/ws", app.share.ServeWS)
+
+	// Token, IP allowlist and CORS origins apply to every endpoint
+	policy, err := NewAccessPolicy(profile)
+	if err != nil {
+		runtime.LogErrorf(ctx, "Access policy: %v; only loopback clients will be accepted", err)
+	}
+	policy.onReject = func(reason string, r *http.Request) {
+		app.rejections.Add(reason)
+		app.metrics.Inc(metricMessagesRejected, profile.Name, reason)
+		runtime.LogWarningf(ctx, "Rejected %s %s from %s (%s)", r.Method, r.URL.Path, r.RemoteAddr, reason)
+	}
+
 	serverAddr := fmt.Sprintf("%s:%d", host, port)
+	scheme := "http"
+	if profile.TLS {
+		scheme = "https"
+	}
 	runtime.LogInfof(ctx, "Starting HTTP server on %s", serverAddr)
-	runtime.LogInfof(ctx, "Server should be accessible at: http://%s:%d", host, port)
+	runtime.LogInfof(ctx, "Server should be accessible at: %s://%s:%d", scheme, host, port)
 
 	server := &http.Server{
 		Addr:              serverAddr,
-		Handler:           mux,
+		Handler:           policy.Wrap(app.metrics.instrumentHandler(mux)),
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      30 * time.Second,
 		IdleTimeout:       120 * time.Second,
@@ -89,16 +370,58 @@ func StartServer(ctx context.Context, host string, port int, app *App) *http.Ser
 		MaxHeaderBytes:    1 << 20, // 1MB
 	}
 
-	// Start server in background goroutine
-	go func() {
-		runtime.LogInfof(ctx, "About to call ListenAndServe...")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			runtime.LogErrorf(ctx, "HTTP server failed to start: %v", err)
-			runtime.LogErrorf(ctx, "Server address was: %s", serverAddr)
-		} else if err == http.ErrServerClosed {
-			runtime.LogInfof(ctx, "HTTP server closed gracefully")
+	if profile.TLS {
+		certFile, keyFile, err := resolveServerCert(profile)
+		if err == nil {
+			var pair tls.Certificate
+			if pair, err = tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+				server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12}
+				runtime.LogInfof(ctx, "Serving TLS with %s", certFile)
+			}
 		}
-	}()
+		if err != nil {
+			// Never fall back to plain HTTP when the profile asks for TLS
+			runtime.LogErrorf(ctx, "HTTPS server not started: %v", err)
+			return server
+		}
+	}
+
+	// Serve the same handler on a Unix domain socket for local clients
+	if profile.Socket != "" {
+		listener, err := listenUnix(profile)
+		if err != nil {
+			runtime.LogErrorf(ctx, "Unix socket listener failed: %v", err)
+		} else {
+			runtime.LogInfof(ctx, "Listening on unix socket %s", profile.Socket)
+			go func() {
+				if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+					runtime.LogErrorf(ctx, "Unix socket server failed: %v", err)
+				}
+			}()
+		}
+	}
+
+	if profile.Socket != "" && profile.DisableTCP {
+		runtime.LogInfof(ctx, "TCP listener disabled, serving on the unix socket only")
+		server.Addr = ""
+	} else {
+		// Start server in background goroutine
+		go func() {
+			runtime.LogInfof(ctx, "About to call ListenAndServe...")
+			var err error
+			if server.TLSConfig != nil {
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				err = server.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				runtime.LogErrorf(ctx, "HTTP server failed to start: %v", err)
+				runtime.LogErrorf(ctx, "Server address was: %s", serverAddr)
+			} else if err == http.ErrServerClosed {
+				runtime.LogInfof(ctx, "HTTP server closed gracefully")
+			}
+		}()
+	}
 
 	// Monitor context for graceful shutdown
 	go func() {
@@ -118,3 +441,24 @@ func StartServer(ctx context.Context, host string, port int, app *App) *http.Ser
 
 	return server
 }
+
+// writePayloadError reports a rejected payload as {"error": ..., "field": ...}.
+func writePayloadError(w http.ResponseWriter, status int, err error) {
+	var payloadErr *PayloadError
+	if !errors.As(err, &payloadErr) {
+		payloadErr = &PayloadError{Message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payloadErr)
+}
+
+// writeEncodingError reports a body that decodedBody could not open: 415
+// for an unknown Content-Encoding, 400 for a corrupt compressed stream.
+func writeEncodingError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, errUnsupportedEncoding) {
+		status = http.StatusUnsupportedMediaType
+	}
+	writePayloadError(w, status, err)
+}
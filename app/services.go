@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceHeader names the service of a request whose payloads carry none.
+const serviceHeader = "X-VersaDumps-Service"
+
+// maxServiceName bounds service names so a bad client cannot flood the UI.
+const maxServiceName = 64
+
+// maxTrackedServices bounds the services counted in memory.
+const maxTrackedServices = 200
+
+// ServiceSummary describes the dumps received from one service.
+type ServiceSummary struct {
+	Service   string    `json:"service"`
+	Count     int       `json:"count"`
+	Muted     bool      `json:"muted"`
+	FirstSeen time.Time `json:"first_seen,omitempty"`
+	LastSeen  time.Time `json:"last_seen,omitempty"`
+}
+
+// ServiceRegistry counts dumps per service and decides which services reach
+// the UI: muted services never do and, when a focus is set, only the
+// focused services do. It keeps the maxTrackedServices most recently seen
+// services.
+type ServiceRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]*ServiceSummary
+	muted   map[string]bool
+	focus   map[string]bool
+}
+
+// NewServiceRegistry creates an empty ServiceRegistry.
+func NewServiceRegistry() *ServiceRegistry {
+	return &ServiceRegistry{
+		entries: make(map[string]*ServiceSummary),
+		muted:   make(map[string]bool),
+		focus:   make(map[string]bool),
+	}
+}
+
+// normalizeService trims a service name and bounds its length.
+func normalizeService(service string) string {
+	return truncateBytes(strings.TrimSpace(service), maxServiceName)
+}
+
+// requestService returns the service named by the request header.
+func requestService(r *http.Request) string {
+	return normalizeService(r.Header.Get(serviceHeader))
+}
+
+// Observe counts a dump from service received at t. It reports whether the
+// dump should be delivered to the UI and whether the service is new.
+func (s *ServiceRegistry) Observe(service string, at time.Time) (deliver bool, discovered bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if service != "" {
+		entry, ok := s.entries[service]
+		if !ok {
+			if len(s.entries) >= maxTrackedServices {
+				s.evictLocked()
+			}
+			entry = &ServiceSummary{Service: service, FirstSeen: at}
+			s.entries[service] = entry
+			discovered = true
+		}
+		entry.Count++
+		entry.LastSeen = at
+	}
+
+	if s.muted[service] {
+		return false, discovered
+	}
+	if len(s.focus) > 0 && !s.focus[service] {
+		return false, discovered
+	}
+	return true, discovered
+}
+
+// evictLocked forgets the least recently seen service. Its mute and focus
+// settings are kept. Callers hold s.mu.
+func (s *ServiceRegistry) evictLocked() {
+	oldest := ""
+	for service, entry := range s.entries {
+		if oldest == "" || entry.LastSeen.Before(s.entries[oldest].LastSeen) {
+			oldest = service
+		}
+	}
+	delete(s.entries, oldest)
+}
+
+// SetMuted replaces the muted service set.
+func (s *ServiceRegistry) SetMuted(services []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = make(map[string]bool, len(services))
+	for _, service := range services {
+		s.muted[service] = true
+	}
+}
+
+// SetFocus restricts delivery to services; an empty list delivers all.
+func (s *ServiceRegistry) SetFocus(services []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.focus = make(map[string]bool, len(services))
+	for _, service := range services {
+		if service = normalizeService(service); service != "" {
+			s.focus[service] = true
+		}
+	}
+}
+
+// Focus returns the focused services, sorted.
+func (s *ServiceRegistry) Focus() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	focus := make([]string, 0, len(s.focus))
+	for service := range s.focus {
+		focus = append(focus, service)
+	}
+	sort.Strings(focus)
+	return focus
+}
+
+// Summary returns the summary of one service, or nil if it was never seen.
+func (s *ServiceRegistry) Summary(service string) *ServiceSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[service]
+	if !ok {
+		return nil
+	}
+	summary := *entry
+	summary.Muted = s.muted[service]
+	return &summary
+}
+
+// Summaries returns every known service by name. Muted services that have
+// not been seen yet are included with a zero count so they can be unmuted.
+func (s *ServiceRegistry) Summaries() []ServiceSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := make([]ServiceSummary, 0, len(s.entries))
+	for service, entry := range s.entries {
+		summary := *entry
+		summary.Muted = s.muted[service]
+		summaries = append(summaries, summary)
+	}
+	for service := range s.muted {
+		if _, seen := s.entries[service]; !seen {
+			summaries = append(summaries, ServiceSummary{Service: service, Muted: true})
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Service < summaries[j].Service })
+	return summaries
+}
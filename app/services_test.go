@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServiceRegistry tests counting, muting and focusing services
+func TestServiceRegistry(t *testing.T) {
+	registry := NewServiceRegistry()
+	start := time.Now()
+
+	deliver, discovered := registry.Observe("auth", start)
+	if !deliver || !discovered {
+		t.Errorf("First dump from auth: deliver=%v discovered=%v", deliver, discovered)
+	}
+	registry.Observe("auth", start.Add(time.Second))
+	registry.Observe("billing", start)
+	if deliver, discovered := registry.Observe("", start); !deliver || discovered {
+		t.Error("Dumps without a service should be delivered but not registered")
+	}
+
+	registry.SetMuted([]string{"billing", "mailer"})
+	if deliver, _ := registry.Observe("billing", start); deliver {
+		t.Error("Muted service should not be delivered")
+	}
+
+	summaries := registry.Summaries()
+	if len(summaries) != 3 {
+		t.Fatalf("Expected auth, billing and mailer, got %+v", summaries)
+	}
+	auth, billing, mailer := summaries[0], summaries[1], summaries[2]
+	if auth.Service != "auth" || auth.Count != 2 || !auth.LastSeen.Equal(start.Add(time.Second)) || !auth.FirstSeen.Equal(start) {
+		t.Errorf("Unexpected auth summary: %+v", auth)
+	}
+	if billing.Count != 2 || !billing.Muted {
+		t.Errorf("Unexpected billing summary: %+v", billing)
+	}
+	if mailer.Count != 0 || !mailer.Muted {
+		t.Errorf("Muted services should be listed before they are seen: %+v", mailer)
+	}
+
+	registry.SetFocus([]string{" auth ", ""})
+	if deliver, _ := registry.Observe("auth", start); !deliver {
+		t.Error("Focused service should be delivered")
+	}
+	if deliver, _ := registry.Observe("", start); deliver {
+		t.Error("Dumps without a service should be hidden while a focus is set")
+	}
+	if focus := registry.Focus(); len(focus) != 1 || focus[0] != "auth" {
+		t.Errorf("Unexpected focus: %v", focus)
+	}
+}
+
+// TestServiceRegistry_Eviction tests that the least recently seen services are dropped first
+func TestServiceRegistry_Eviction(t *testing.T) {
+	registry := NewServiceRegistry()
+	start := time.Now()
+	for i := 0; i < maxTrackedServices; i++ {
+		registry.Observe(fmt.Sprintf("svc-%d", i), start.Add(time.Duration(i)*time.Second))
+	}
+	registry.Observe("svc-0", start.Add(time.Hour))
+	registry.Observe("new", start.Add(time.Hour))
+
+	if n := len(registry.Summaries()); n != maxTrackedServices {
+		t.Errorf("Expected %d services, got %d", maxTrackedServices, n)
+	}
+	if registry.Summary("svc-1") != nil || registry.Summary("svc-0") == nil || registry.Summary("new") == nil {
+		t.Error("The least recently seen service should be evicted first")
+	}
+}
+
+// TestDumpService tests reading the service from the payload and the header
+func TestDumpService(t *testing.T) {
+	testCases := []struct {
+		body     string
+		expected string
+	}{
+		{`{"context": 1, "service": "auth"}`, "auth"},
+		{`{"context": 1, "service": "auth", "metadata": {"service": "billing"}}`, "billing"},
+		{`{"context": 1, "service": "  ` + strings.Repeat("s", 100) + `"}`, strings.Repeat("s", maxServiceName)},
+		{`{"context": 1, "service": "` + strings.Repeat("s", maxServiceName-1) + `é"}`, strings.Repeat("s", maxServiceName-1)},
+		{`{"context": 1}`, ""},
+	}
+
+	for _, tc := range testCases {
+		msg, err := ParseDumpMessage([]byte(tc.body))
+		if err != nil {
+			t.Fatalf("ParseDumpMessage(%s) failed: %v", tc.body, err)
+		}
+		if msg.Service != tc.expected {
+			t.Errorf("Expected service %q, got %q", tc.expected, msg.Service)
+		}
+	}
+
+	if _, err := ParseDumpMessage([]byte(`{"context": 1, "service": 5}`)); err == nil {
+		t.Error("A non-string service should be rejected")
+	}
+
+	r := httptest.NewRequest("POST", "/data", nil)
+	r.Header.Set(serviceHeader, " worker ")
+	if service := requestService(r); service != "worker" {
+		t.Errorf("Expected service from header, got %q", service)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// shareSendBuffer is how many messages a viewer may fall behind before
+	// it is disconnected.
+	shareSendBuffer = 256
+	shareWriteWait  = 10 * time.Second
+	sharePongWait   = 60 * time.Second
+	sharePingPeriod = 50 * time.Second
+)
+
+// ShareSession describes the active live share.
+type ShareSession struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`    // viewer page to open in a browser
+	WSURL     string    `json:"ws_url"` // raw WebSocket stream
+	StartedAt time.Time `json:"started_at"`
+	Viewers   int       `json:"viewers"`
+}
+
+// ShareEvent is one message streamed to viewers.
+type ShareEvent struct {
+	Type string          `json:"type"` // "dump" or "log"
+	Data json.RawMessage `json:"data"`
+}
+
+type shareClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// ShareHub streams accepted dumps and log lines to read-only WebSocket
+// viewers holding the session token.
+type ShareHub struct {
+	mu        sync.Mutex
+	token     string
+	startedAt time.Time
+	clients   map[*shareClient]bool
+	upgrader  websocket.Upgrader
+}
+
+// NewShareHub creates a hub with no active session.
+func NewShareHub() *ShareHub {
+	return &ShareHub{
+		clients: make(map[*shareClient]bool),
+		upgrader: websocket.Upgrader{
+			// Viewers open the page from another machine; the token is the
+			// access control, not the origin.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Start begins a session with a fresh token. An active session is kept.
+func (h *ShareHub) Start() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.token != "" {
+		return nil
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate share token: %v", err)
+	}
+	h.token = hex.EncodeToString(b)
+	h.startedAt = time.Now()
+	return nil
+}
+
+// Stop ends the session and disconnects every viewer.
+func (h *ShareHub) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.token = ""
+	for c := range h.clients {
+		h.dropLocked(c)
+	}
+}
+
+// Active reports the session token and viewer count, if a session is active.
+func (h *ShareHub) Active() (token string, startedAt time.Time, viewers int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.token, h.startedAt, len(h.clients)
+}
+
+// Broadcast sends data to every viewer. Viewers that fall too far behind
+// are disconnected rather than blocking the caller.
+func (h *ShareHub) Broadcast(eventType string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.token == "" || len(h.clients) == 0 {
+		return
+	}
+
+	msg, err := json.Marshal(ShareEvent{Type: eventType, Data: data})
+	if err != nil {
+		return
+	}
+	for c := range h.clients {
+		select {
+		case c.send <- msg:
+		default:
+			h.dropLocked(c)
+		}
+	}
+}
+
+// dropLocked closes c's send queue; its writer then closes the connection.
+// Callers must hold mu.
+func (h *ShareHub) dropLocked(c *shareClient) {
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// authorized reports whether r carries the active session token.
+func (h *ShareHub) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// ServeWS upgrades an authorized request and streams events until the
+// viewer disconnects or the session stops.
+func (h *ShareHub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "Invalid or expired share token", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already wrote the error response
+	}
+
+	c := &shareClient{conn: conn, send: make(chan []byte, shareSendBuffer)}
+	h.mu.Lock()
+	if h.token == "" {
+		h.mu.Unlock()
+		conn.Close()
+		return
+	}
+	h.clients[c] = true
+	h.mu.Unlock()
+
+	go h.writePump(c)
+	h.readPump(c)
+}
+
+// readPump discards viewer messages (the stream is read-only) and notices
+// when the viewer goes away.
+func (h *ShareHub) readPump(c *shareClient) {
+	defer func() {
+		h.mu.Lock()
+		h.dropLocked(c)
+		h.mu.Unlock()
+	}()
+
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(sharePongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(sharePongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *ShareHub) writePump(c *shareClient) {
+	ticker := time.NewTicker(sharePingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(shareWriteWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "share session ended"))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(shareWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ServeViewer serves a minimal page that renders the stream in a browser.
+func (h *ShareHub) ServeViewer(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "Invalid or expired share token", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(shareViewerHTML))
+}
+
+// shareHost returns the address teammates should use to reach a server
+// bound to host: the first LAN IPv4 address when bound to all interfaces.
+func shareHost(host string) string {
+	if host != "" && host != "0.0.0.0" && host != "::" {
+		return host
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+				return ipNet.IP.String()
+			}
+		}
+	}
+	return "127.0.0.1"
+}
+
+const shareViewerHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>VersaDumps - Live Session</title>
+<style>
+body { font-family: ui-monospace, monospace; background: #1e1e1e; color: #d4d4d4; margin: 0; padding: 1rem; }
+#status { color: #888; margin-bottom: 1rem; }
+.entry { border-left: 3px solid #569cd6; background: #252526; margin: 0 0 .75rem; padding: .5rem .75rem; }
+.entry.log { border-color: #ce9178; }
+.meta { color: #888; font-size: .85em; margin-bottom: .25rem; }
+pre { margin: 0; white-space: pre-wrap; word-break: break-word; }
+</style>
+</head>
+<body>
+<div id="status">Connecting...</div>
+<div id="entries"></div>
+<script>
+const statusEl = document.getElementById('status');
+const entries = document.getElementById('entries');
+const wsURL = location.origin.replace(/^http/, 'ws') + '/share/ws' + location.search;
+const ws = new WebSocket(wsURL);
+ws.onopen = () => { statusEl.textContent = 'Connected - read-only live session'; };
+ws.onclose = (e) => { statusEl.textContent = 'Disconnected' + (e.reason ? ': ' + e.reason : ''); };
+ws.onmessage = (e) => {
+  const ev = JSON.parse(e.data);
+  const div = document.createElement('div');
+  div.className = 'entry ' + ev.type;
+  const meta = document.createElement('div');
+  meta.className = 'meta';
+  const pre = document.createElement('pre');
+  if (ev.type === 'log') {
+    meta.textContent = ev.data.fileName + ':' + ev.data.lineNum + ' ' + (ev.data.level || '');
+    pre.textContent = ev.data.line;
+  } else {
+    const frame = ev.data.frame ? ev.data.frame.file + ':' + ev.data.frame.line : '';
+    meta.textContent = [ev.data.label, frame, ev.data.received_at].filter(Boolean).join('  ');
+    pre.textContent = JSON.stringify(ev.data.context, null, 2);
+  }
+  div.append(meta, pre);
+  entries.prepend(div);
+};
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newShareTestServer serves hub on a local test server
+func newShareTestServer(t *testing.T, hub *ShareHub) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/share", hub.ServeViewer)
+	mux.HandleFunc("/share/ws", hub.ServeWS)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialShare connects a viewer with token
+func dialShare(server *httptest.Server, token string) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/share/ws?token=" + token
+	return websocket.DefaultDialer.Dial(url, nil)
+}
+
+// waitForViewers waits until the hub has registered n viewers
+func waitForViewers(t *testing.T, hub *ShareHub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, _, viewers := hub.Active(); viewers == n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d viewers", n)
+}
+
+// TestShareHub_Stream tests that an authorized viewer receives broadcast events
+func TestShareHub_Stream(t *testing.T) {
+	hub := NewShareHub()
+	server := newShareTestServer(t, hub)
+	if err := hub.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	token, _, _ := hub.Active()
+
+	conn, _, err := dialShare(server, token)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	waitForViewers(t, hub, 1)
+
+	hub.Broadcast("dump", []byte(`{"id":1,"context":{"a":1}}`))
+	hub.Broadcast("log", []byte(`{"line":"boot"}`))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, expected := range []string{"dump", "log"} {
+		var ev ShareEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("ReadJSON failed: %v", err)
+		}
+		if ev.Type != expected || !json.Valid(ev.Data) {
+			t.Errorf("Unexpected event: %s %s", ev.Type, ev.Data)
+		}
+	}
+
+	hub.Stop()
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected a normal close after Stop(), got %v", err)
+	}
+}
+
+// TestShareHub_Unauthorized tests that viewers need the active token
+func TestShareHub_Unauthorized(t *testing.T) {
+	hub := NewShareHub()
+	server := newShareTestServer(t, hub)
+
+	if _, resp, err := dialShare(server, ""); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Error("Dial should fail without an active session")
+	}
+
+	hub.Start()
+	token, _, _ := hub.Active()
+	if _, resp, err := dialShare(server, "wrong"); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Error("Dial should fail with a wrong token")
+	}
+
+	resp, err := http.Get(server.URL + "/share?token=" + token)
+	if err != nil {
+		t.Fatalf("GET viewer failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected viewer page, got %d", resp.StatusCode)
+	}
+
+	hub.Stop()
+	hub.Start()
+	if newToken, _, _ := hub.Active(); newToken == token {
+		t.Error("A new session should use a new token")
+	}
+}
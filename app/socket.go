@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	gosys "runtime"
+	"strconv"
+	"time"
+)
+
+// defaultSocketMode keeps the socket private to the user running VersaDumps.
+const defaultSocketMode os.FileMode = 0600
+
+// parseSocketMode parses an octal permission string such as "0660".
+func parseSocketMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return defaultSocketMode, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, fmt.Errorf("invalid socket_mode '%s', expected an octal mode such as 0660", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// listenUnix listens on the Unix domain socket of profile and applies its
+// permissions. A stale socket left by a crash is replaced; a socket another
+// process still answers on is not.
+func listenUnix(profile Profile) (net.Listener, error) {
+	path := profile.Socket
+	mode, err := parseSocketMode(profile.SocketMode)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("'%s' exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket '%s' is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket '%s': %v", path, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %v", err)
+	}
+	if profile.SocketGroup != "" {
+		if err := chownSocketGroup(path, profile.SocketGroup); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// chownSocketGroup gives group (a name or numeric id) access to the socket,
+// e.g. so PHP-FPM workers running as www-data can write to it.
+func chownSocketGroup(path, group string) error {
+	if gosys.GOOS == "windows" {
+		return fmt.Errorf("socket_group is not supported on Windows")
+	}
+	gid, err := strconv.Atoi(group)
+	if err != nil {
+		g, lookupErr := user.LookupGroup(group)
+		if lookupErr != nil {
+			return fmt.Errorf("unknown socket_group '%s'", group)
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return fmt.Errorf("unknown socket_group '%s'", group)
+		}
+	}
+	if err := os.Chown(path, -1, gid); err != nil {
+		return fmt.Errorf("failed to set socket group '%s': %v", group, err)
+	}
+	return nil
+}
+
+// unixHTTPClient returns a client that sends every request to the socket
+// at path, whatever the URL host.
+func unixHTTPClient(path string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
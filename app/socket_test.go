@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	gosys "runtime"
+	"testing"
+	"time"
+)
+
+// shortSocketDir returns a temp dir short enough for a socket path
+func shortSocketDir(t *testing.T) string {
+	t.Helper()
+	if gosys.GOOS == "windows" {
+		t.Skip("socket permissions are POSIX only")
+	}
+	dir, err := os.MkdirTemp("", "vd")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// TestListenUnix tests serving HTTP on a socket with the configured mode
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(shortSocketDir(t), "vd.sock")
+	listener, err := listenUnix(Profile{Socket: path, SocketMode: "0660"})
+	if err != nil {
+		t.Fatalf("listenUnix() failed: %v", err)
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Socket was not created: %v", err)
+	}
+	if info.Mode().Perm() != 0660 {
+		t.Errorf("Expected mode 0660, got %v", info.Mode().Perm())
+	}
+
+	resp, err := unixHTTPClient(path, time.Second).Get("http://versadumps/health")
+	if err != nil {
+		t.Fatalf("Request over socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"status":"ok"}` {
+		t.Errorf("Unexpected body: %s", body)
+	}
+
+	if _, err := listenUnix(Profile{Socket: path}); err == nil {
+		t.Error("listenUnix() should refuse a socket that is in use")
+	}
+}
+
+// TestListenUnix_ExistingPaths tests stale sockets and non-socket files
+func TestListenUnix_ExistingPaths(t *testing.T) {
+	dir := shortSocketDir(t)
+
+	stale := filepath.Join(dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	// Leave the file behind as a crashed process would
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	listener, err := listenUnix(Profile{Socket: stale})
+	if err != nil {
+		t.Fatalf("listenUnix() should replace a stale socket: %v", err)
+	}
+	listener.Close()
+
+	regular := filepath.Join(dir, "file.sock")
+	os.WriteFile(regular, []byte("keep me"), 0644)
+	if _, err := listenUnix(Profile{Socket: regular}); err == nil {
+		t.Error("listenUnix() should not replace a regular file")
+	}
+	if data, _ := os.ReadFile(regular); string(data) != "keep me" {
+		t.Error("Regular file was modified")
+	}
+}
+
+// TestParseSocketMode tests reading octal permissions
+func TestParseSocketMode(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected os.FileMode
+		valid    bool
+	}{
+		{"", defaultSocketMode, true},
+		{"0660", 0660, true},
+		{"777", 0777, true},
+		{"0999", 0, false},
+		{"1777", 0, false},
+		{"rw-rw----", 0, false},
+	}
+
+	for _, tc := range testCases {
+		mode, err := parseSocketMode(tc.mode)
+		if tc.valid && (err != nil || mode != tc.expected) {
+			t.Errorf("parseSocketMode(%q) = %v, %v", tc.mode, mode, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("parseSocketMode(%q) should fail", tc.mode)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// MessageCounts summarises the dumps seen by the app.
+type MessageCounts struct {
+	Received int64 `json:"received"` // accepted by the listener that answered
+	Total    int64 `json:"total"`    // accepted by every running profile
+	Retained int   `json:"retained"` // kept in memory for queries
+}
+
+// ServerStatus is what /health and /status report, so clients can tell which
+// version and profile they talk to before sending.
+type ServerStatus struct {
+	Status        string                 `json:"status"` // always "ok" when answered
+	Version       string                 `json:"version"`
+	Profile       string                 `json:"profile"`        // profile of the listener that answered
+	ActiveProfile string                 `json:"active_profile"` // profile the UI is showing
+	StartedAt     time.Time              `json:"started_at"`
+	UptimeSeconds float64                `json:"uptime_seconds"`
+	Listeners     []string               `json:"listeners"`
+	Messages      MessageCounts          `json:"messages"`
+	LogWatcher    map[string]interface{} `json:"log_watcher"`
+	UIAttached    bool                   `json:"ui_attached"`
+
+	// Only on /status
+	Profiles []ProfileStatus `json:"profiles,omitempty"`
+	Delivery *DeliveryStats  `json:"delivery,omitempty"`
+}
+
+// listenerAddresses returns the URLs a profile listener answers on.
+func listenerAddresses(server *http.Server, profile Profile) []string {
+	addresses := []string{}
+	if server == nil {
+		return addresses
+	}
+	if server.Addr != "" {
+		scheme := "http"
+		if server.TLSConfig != nil {
+			scheme = "https"
+		}
+		addresses = append(addresses, scheme+"://"+server.Addr)
+	}
+	if profile.Socket != "" {
+		addresses = append(addresses, "unix://"+profile.Socket)
+	}
+	return addresses
+}
+
+// serverStatus builds the status reported by the listener of profileName;
+// detailed adds every running profile and the UI delivery counters.
+func (a *App) serverStatus(profileName, activeProfile string, detailed bool) ServerStatus {
+	server, profile := a.profiles.Server(profileName)
+	profiles := a.profiles.Status(activeProfile)
+
+	status := ServerStatus{
+		Status:        "ok",
+		Version:       CurrentVersion,
+		Profile:       profileName,
+		ActiveProfile: activeProfile,
+		StartedAt:     a.startedAt,
+		UptimeSeconds: time.Since(a.startedAt).Seconds(),
+		Listeners:     listenerAddresses(server, profile),
+		Messages:      MessageCounts{Retained: a.dumps.Len()},
+		LogWatcher:    map[string]interface{}{"running": false, "folderCount": 0, "fileCount": 0},
+		UIAttached:    a.uiAttached.Load(),
+	}
+	for _, p := range profiles {
+		status.Messages.Total += p.Messages
+		if p.Name == profileName {
+			status.Messages.Received = p.Messages
+		}
+	}
+	if watcher := a.profiles.Watcher(profileName); watcher != nil {
+		status.LogWatcher = watcher.GetStatus()
+	}
+
+	if detailed {
+		status.Profiles = profiles
+		delivery := a.delivery.Stats()
+		status.Delivery = &delivery
+	}
+	return status
+}
+
+// writeServerStatus answers /health and /status with the status of profileName.
+func (a *App) writeServerStatus(w http.ResponseWriter, profileName string, detailed bool) {
+	activeProfile, _ := a.activeProfileName()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(a.serverStatus(profileName, activeProfile, detailed))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestServerStatus tests the report served on /health and /status
+func TestServerStatus(t *testing.T) {
+	var started []string
+	app := NewApp()
+	app.profiles = newTestSupervisor(&started)
+	defer app.profiles.StopAll()
+
+	app.profiles.StartServer(context.Background(), Profile{Name: "api", Server: "localhost", Port: 9191, Socket: "/tmp/api.sock"})
+	app.profiles.StartServer(context.Background(), Profile{Name: "worker", Server: "localhost", Port: 9192})
+	app.profiles.CountMessage("api")
+	app.profiles.CountMessage("worker")
+	app.profiles.CountMessage("worker")
+	app.dumps.Add(&DumpMessage{ID: 1, ReceivedAt: time.Now()})
+	app.uiAttached.Store(true)
+
+	status := app.serverStatus("api", "worker", false)
+	if status.Status != "ok" || status.Version != CurrentVersion || status.Profile != "api" || status.ActiveProfile != "worker" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if status.Messages != (MessageCounts{Received: 1, Total: 3, Retained: 1}) {
+		t.Errorf("Unexpected message counts: %+v", status.Messages)
+	}
+	if len(status.Listeners) != 2 || status.Listeners[0] != "http://localhost:9191" || status.Listeners[1] != "unix:///tmp/api.sock" {
+		t.Errorf("Unexpected listeners: %v", status.Listeners)
+	}
+	if status.LogWatcher["running"] != false || !status.UIAttached || status.UptimeSeconds < 0 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if status.Profiles != nil || status.Delivery != nil {
+		t.Error("/health should not include the detailed fields")
+	}
+
+	detailed := app.serverStatus("api", "worker", true)
+	if len(detailed.Profiles) != 2 || detailed.Delivery == nil {
+		t.Errorf("/status should list profiles and delivery stats: %+v", detailed)
+	}
+}
+
+// TestWriteServerStatus_NoConfigRead tests that /health answers from memory
+func TestWriteServerStatus_NoConfigRead(t *testing.T) {
+	originalConfigDirFunc := ConfigDirFunc
+	ConfigDirFunc = func() (string, error) {
+		t.Error("/health should not read the config")
+		return "", fmt.Errorf("no config in this test")
+	}
+	defer func() { ConfigDirFunc = originalConfigDirFunc }()
+
+	app := NewApp()
+	app.activeName.Store("worker")
+	rec := httptest.NewRecorder()
+	app.writeServerStatus(rec, "api", false)
+
+	var status ServerStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("Invalid status: %v", err)
+	}
+	if status.ActiveProfile != "worker" || status.Version != CurrentVersion {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+// TestListenerAddresses tests the schemes reported for each listener
+func TestListenerAddresses(t *testing.T) {
+	tlsServer := &http.Server{Addr: "0.0.0.0:9191", TLSConfig: &tls.Config{}}
+	if got := listenerAddresses(tlsServer, Profile{}); len(got) != 1 || got[0] != "https://0.0.0.0:9191" {
+		t.Errorf("Unexpected TLS listener: %v", got)
+	}
+	socketOnly := &http.Server{}
+	if got := listenerAddresses(socketOnly, Profile{Socket: "/tmp/v.sock"}); len(got) != 1 || got[0] != "unix:///tmp/v.sock" {
+		t.Errorf("Unexpected socket listener: %v", got)
+	}
+	if got := listenerAddresses(nil, Profile{Socket: "/tmp/v.sock"}); len(got) != 0 {
+		t.Errorf("A stopped profile has no listeners, got %v", got)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DumpStore persists received dumps in an embedded SQLite database so a
+// session can be reloaded after the app restarts or crashes.
+type DumpStore struct {
+	db   *sql.DB
+	path string
+}
+
+// HistoryRecord is a stored dump as returned to the frontend.
+type HistoryRecord struct {
+	ID         int64     `json:"id"`
+	ReceivedAt time.Time `json:"received_at"`
+	Profile    string    `json:"profile"`
+	Label      string    `json:"label,omitempty"`
+	Color      string    `json:"color,omitempty"`
+	File       string    `json:"file,omitempty"`
+	Line       int       `json:"line,omitempty"`
+	Payload    string    `json:"payload"` // canonical DumpMessage JSON
+}
+
+// HistoryPage is one page of history, newest first.
+type HistoryPage struct {
+	Records  []HistoryRecord `json:"records"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// Paging bounds. maxHistoryPage keeps (page-1)*pageSize far from
+// overflowing an int.
+const (
+	defaultHistoryPageSize = 100
+	maxHistoryPageSize     = 1000
+	maxHistoryPage         = 1000000
+)
+
+// Retention defaults: each profile keeps at most this many stored dumps,
+// none older than this many days, and is pruned every historyPruneInterval
+// saves.
+const (
+	defaultHistoryMaxDumps = 10000
+	defaultHistoryMaxDays  = 30
+	historyPruneInterval   = 100
+)
+
+const dumpStoreSchema = `
+CREATE TABLE IF NOT EXISTS dumps (
+	id          INTEGER PRIMARY KEY,
+	received_at INTEGER NOT NULL,
+	profile     TEXT    NOT NULL,
+	label       TEXT    NOT NULL DEFAULT '',
+	color       TEXT    NOT NULL DEFAULT '',
+	file        TEXT    NOT NULL DEFAULT '',
+	line        INTEGER NOT NULL DEFAULT 0,
+	payload     TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_dumps_profile_received ON dumps(profile, received_at DESC);
+`
+
+// profileHistoryRetention returns how many stored dumps profile keeps and
+// for how long.
+func profileHistoryRetention(profile Profile) (int, time.Duration) {
+	maxDumps, maxDays := defaultHistoryMaxDumps, defaultHistoryMaxDays
+	if profile.HistoryLimit > 0 {
+		maxDumps = profile.HistoryLimit
+	}
+	if profile.HistoryDays > 0 {
+		maxDays = profile.HistoryDays
+	}
+	return maxDumps, time.Duration(maxDays) * 24 * time.Hour
+}
+
+// getHistoryPath returns the path of the history database next to config.yml.
+func getHistoryPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "history.db"), nil
+}
+
+// OpenDumpStore opens (creating if needed) the history database at path.
+func OpenDumpStore(path string) (*DumpStore, error) {
+	dsn := "file:" + filepath.ToSlash(path) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %v", err)
+	}
+	// SQLite allows a single writer; serialize access instead of fighting over locks.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(dumpStoreSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history database: %v", err)
+	}
+	return &DumpStore{db: db, path: path}, nil
+}
+
+// Close releases the database handle.
+func (s *DumpStore) Close() error {
+	return s.db.Close()
+}
+
+// Save stores a dump received for profile. payload is the canonical JSON of msg.
+func (s *DumpStore) Save(profile string, msg *DumpMessage, payload []byte) error {
+	var file string
+	var line int
+	if msg.Frame != nil {
+		file = msg.Frame.File
+		line = msg.Frame.Line
+	}
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO dumps (id, received_at, profile, label, color, file, line, payload)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		msg.ID, msg.ReceivedAt.UnixMilli(), profile, msg.Label, msg.Color, file, line, string(payload),
+	)
+	return err
+}
+
+// List returns a page of dumps for profile, newest first. A non-empty search
+// matches label, file or payload text (case-insensitive). Pages start at 1.
+func (s *DumpStore) List(profile, search string, page, pageSize int) (*HistoryPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if page > maxHistoryPage {
+		page = maxHistoryPage
+	}
+	if pageSize <= 0 {
+		pageSize = defaultHistoryPageSize
+	}
+	if pageSize > maxHistoryPageSize {
+		pageSize = maxHistoryPageSize
+	}
+
+	where := "profile = ?"
+	args := []interface{}{profile}
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		where += ` AND (label LIKE ? ESCAPE '\' OR file LIKE ? ESCAPE '\' OR payload LIKE ? ESCAPE '\')`
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	result := &HistoryPage{Records: []HistoryRecord{}, Page: page, PageSize: pageSize}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM dumps WHERE "+where, args...).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(
+		"SELECT id, received_at, profile, label, color, file, line, payload FROM dumps WHERE "+where+
+			" ORDER BY received_at DESC, id DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec, err := scanHistoryRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Records = append(result.Records, *rec)
+	}
+	return result, rows.Err()
+}
+
+// Messages returns the stored dumps of profile, oldest first, narrowed by
+// the label, color, file and time range of q. Filters that need the decoded
+// payload are left to RunDumpQuery, which applies all of q again.
+func (s *DumpStore) Messages(profile string, q DumpQuery) ([]*DumpMessage, error) {
+	where := "profile = ?"
+	args := []interface{}{profile}
+	if q.Label != "" {
+		where += " AND label = ? COLLATE NOCASE"
+		args = append(args, q.Label)
+	}
+	if q.Color != "" {
+		where += " AND color = ? COLLATE NOCASE"
+		args = append(args, q.Color)
+	}
+	if q.File != "" {
+		where += ` AND file LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(q.File)+"%")
+	}
+	// Stored times are in milliseconds; RunDumpQuery applies the exact bounds
+	if !q.Since.IsZero() {
+		where += " AND received_at >= ?"
+		args = append(args, q.Since.UnixMilli())
+	}
+	if !q.Until.IsZero() {
+		where += " AND received_at <= ?"
+		args = append(args, q.Until.UnixMilli())
+	}
+
+	rows, err := s.db.Query("SELECT payload FROM dumps WHERE "+where+" ORDER BY received_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*DumpMessage{}
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var msg DumpMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			continue
+		}
+		msg.Profile = profile
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+// Get returns a single stored dump, or nil if it does not exist.
+func (s *DumpStore) Get(id int64) (*HistoryRecord, error) {
+	row := s.db.QueryRow(
+		"SELECT id, received_at, profile, label, color, file, line, payload FROM dumps WHERE id = ?", id)
+	rec, err := scanHistoryRecord(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rec, err
+}
+
+// Delete removes a single dump of profile.
+func (s *DumpStore) Delete(profile string, id int64) error {
+	res, err := s.db.Exec("DELETE FROM dumps WHERE id = ? AND profile = ?", id, profile)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("history entry %d not found", id)
+	}
+	return nil
+}
+
+// Prune removes the dumps of profile beyond the newest maxDumps and those
+// received more than maxAge ago, returning how many it removed. A zero
+// limit is not enforced.
+func (s *DumpStore) Prune(profile string, maxDumps int, maxAge time.Duration) (int64, error) {
+	var removed int64
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge).UnixMilli()
+		res, err := s.db.Exec("DELETE FROM dumps WHERE profile = ? AND received_at < ?", profile, cutoff)
+		if err != nil {
+			return removed, err
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+	if maxDumps > 0 {
+		res, err := s.db.Exec(
+			`DELETE FROM dumps WHERE id IN (
+				SELECT id FROM dumps WHERE profile = ?
+				ORDER BY received_at DESC, id DESC LIMIT -1 OFFSET ?)`,
+			profile, maxDumps,
+		)
+		if err != nil {
+			return removed, err
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+	return removed, nil
+}
+
+// Clear removes every dump stored for profile.
+func (s *DumpStore) Clear(profile string) error {
+	_, err := s.db.Exec("DELETE FROM dumps WHERE profile = ?", profile)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHistoryRecord(row rowScanner) (*HistoryRecord, error) {
+	var rec HistoryRecord
+	var receivedAt int64
+	if err := row.Scan(&rec.ID, &receivedAt, &rec.Profile, &rec.Label, &rec.Color,
+		&rec.File, &rec.Line, &rec.Payload); err != nil {
+		return nil, err
+	}
+	rec.ReceivedAt = time.UnixMilli(receivedAt)
+	return &rec, nil
+}
+
+// escapeLike escapes LIKE wildcards so search text is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
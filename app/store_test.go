@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestStore opens a DumpStore in a temporary directory
+func openTestStore(t *testing.T) *DumpStore {
+	t.Helper()
+	store, err := OpenDumpStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenDumpStore() failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// saveTestDump stores a dump with the given label and file
+func saveTestDump(t *testing.T, store *DumpStore, profile, label, file string, at time.Time) *DumpMessage {
+	t.Helper()
+	msg := &DumpMessage{
+		Version:    DumpSchemaVersion,
+		ID:         nextDumpID(),
+		ReceivedAt: at,
+		Label:      label,
+		Context:    map[string]interface{}{"value": label},
+		Frame:      &DumpFrame{File: file, Line: 10},
+	}
+	payload, _ := json.Marshal(msg)
+	if err := store.Save(profile, msg, payload); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	return msg
+}
+
+// TestDumpStore_ListAndSearch tests paging and searching stored dumps
+func TestDumpStore_ListAndSearch(t *testing.T) {
+	store := openTestStore(t)
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		saveTestDump(t, store, "Default", "user", "/app/User.php", base.Add(time.Duration(i)*time.Second))
+	}
+	saveTestDump(t, store, "Default", "order_100%", "/app/Order.php", base.Add(10*time.Second))
+	saveTestDump(t, store, "Other", "user", "/app/User.php", base)
+
+	page, err := store.List("Default", "", 1, 4)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if page.Total != 6 {
+		t.Errorf("Expected total 6, got %d", page.Total)
+	}
+	if len(page.Records) != 4 {
+		t.Fatalf("Expected 4 records on page 1, got %d", len(page.Records))
+	}
+	if page.Records[0].Label != "order_100%" {
+		t.Errorf("Expected newest record first, got '%s'", page.Records[0].Label)
+	}
+
+	page, err = store.List("Default", "", 2, 4)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(page.Records) != 2 {
+		t.Errorf("Expected 2 records on page 2, got %d", len(page.Records))
+	}
+
+	// Wildcards in search text are matched literally
+	page, err = store.List("Default", "100%", 1, 10)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if page.Total != 1 {
+		t.Errorf("Expected 1 match for '100%%', got %d", page.Total)
+	}
+
+	page, err = store.List("Default", "User.php", 1, 10)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if page.Total != 5 {
+		t.Errorf("Expected 5 matches for file search, got %d", page.Total)
+	}
+}
+
+// TestDumpStore_Messages tests querying stored dumps past the in-memory window
+func TestDumpStore_Messages(t *testing.T) {
+	store := openTestStore(t)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < defaultDumpRetention+10; i++ {
+		saveTestDump(t, store, "Default", "user", "/app/User.php", base.Add(time.Duration(i)*time.Millisecond))
+	}
+	oldest := saveTestDump(t, store, "Default", "order", "/app/Order.php", base.Add(-time.Minute))
+	saveTestDump(t, store, "Other", "order", "/app/Order.php", base)
+
+	messages, err := store.Messages("Default", DumpQuery{Label: "ORDER", File: "order.php"})
+	if err != nil {
+		t.Fatalf("Messages() failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != oldest.ID || messages[0].Profile != "Default" {
+		t.Fatalf("Expected the oldest order dump of Default, got %d messages", len(messages))
+	}
+
+	query := DumpQuery{Profile: "Default", Where: []string{"context.value == user"}, Until: base.Add(4 * time.Millisecond)}
+	messages, _ = store.Messages("Default", query)
+	result, err := RunDumpQuery(messages, query)
+	if err != nil {
+		t.Fatalf("RunDumpQuery() failed: %v", err)
+	}
+	if result.Total != 5 {
+		t.Errorf("Expected 5 stored user dumps up to the bound, got %d", result.Total)
+	}
+}
+
+// TestDumpStore_DeleteAndClear tests removing stored dumps
+func TestDumpStore_DeleteAndClear(t *testing.T) {
+	store := openTestStore(t)
+
+	msg := saveTestDump(t, store, "Default", "a", "/a.php", time.Now())
+	saveTestDump(t, store, "Default", "b", "/b.php", time.Now())
+	other := saveTestDump(t, store, "Other", "c", "/c.php", time.Now())
+
+	rec, err := store.Get(msg.ID)
+	if err != nil || rec == nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if rec.File != "/a.php" || rec.Line != 10 {
+		t.Errorf("Unexpected record: %+v", rec)
+	}
+
+	if err := store.Delete("Default", msg.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := store.Delete("Default", msg.ID); err == nil {
+		t.Error("Delete() should fail for a missing entry")
+	}
+	if err := store.Delete("Default", other.ID); err == nil {
+		t.Error("Delete() should not remove an entry of another profile")
+	}
+
+	if err := store.Clear("Default"); err != nil {
+		t.Fatalf("Clear() failed: %v", err)
+	}
+	page, _ := store.List("Default", "", 1, 10)
+	if page.Total != 0 {
+		t.Errorf("Expected empty history after Clear(), got %d", page.Total)
+	}
+	page, _ = store.List("Other", "", 1, 10)
+	if page.Total != 1 {
+		t.Errorf("Clear() should not touch other profiles, got %d", page.Total)
+	}
+}
+
+// TestDumpStore_Prune tests the age and size caps of stored history
+func TestDumpStore_Prune(t *testing.T) {
+	store := openTestStore(t)
+
+	now := time.Now()
+	saveTestDump(t, store, "Default", "stale", "/a.php", now.Add(-48*time.Hour))
+	for i := 0; i < 5; i++ {
+		saveTestDump(t, store, "Default", "fresh", "/a.php", now.Add(time.Duration(i)*time.Second))
+	}
+	saveTestDump(t, store, "Other", "stale", "/a.php", now.Add(-48*time.Hour))
+
+	removed, err := store.Prune("Default", 3, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Prune() failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Expected 3 dumps pruned, got %d", removed)
+	}
+	page, _ := store.List("Default", "", 1, 10)
+	if page.Total != 3 || page.Records[2].ReceivedAt.UnixMilli() != now.Add(2*time.Second).UnixMilli() {
+		t.Errorf("Expected the 3 newest dumps kept, got %+v", page.Records)
+	}
+	page, _ = store.List("Other", "", 1, 10)
+	if page.Total != 1 {
+		t.Errorf("Prune() should not touch other profiles, got %d", page.Total)
+	}
+
+	if removed, _ := store.Prune("Default", 0, 0); removed != 0 {
+		t.Errorf("Zero limits should keep everything, pruned %d", removed)
+	}
+}
+
+// TestProfileHistoryRetention tests the retention defaults and overrides
+func TestProfileHistoryRetention(t *testing.T) {
+	maxDumps, maxAge := profileHistoryRetention(Profile{})
+	if maxDumps != defaultHistoryMaxDumps || maxAge != defaultHistoryMaxDays*24*time.Hour {
+		t.Errorf("Unexpected defaults: %d, %v", maxDumps, maxAge)
+	}
+	maxDumps, maxAge = profileHistoryRetention(Profile{HistoryLimit: 50, HistoryDays: 2})
+	if maxDumps != 50 || maxAge != 48*time.Hour {
+		t.Errorf("Unexpected retention: %d, %v", maxDumps, maxAge)
+	}
+}
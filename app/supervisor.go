@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProfileStatus describes a profile the supervisor is running.
+type ProfileStatus struct {
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"` // the profile the UI is editing
+	Address   string    `json:"address,omitempty"`
+	Socket    string    `json:"socket,omitempty"`
+	TLS       bool      `json:"tls"`
+	Watching  bool      `json:"watching"` // a log watcher is running
+	StartedAt time.Time `json:"started_at"`
+	Messages  int64     `json:"messages"`
+}
+
+// profilePipeline holds the components the dumps and log lines of one
+// profile pass through, so each running profile applies its own labels,
+// mutes, dedupe window, alert rules and history setting.
+type profilePipeline struct {
+	name       string
+	labels     *LabelIndex // latest dump and history per label
+	services   *ServiceRegistry
+	dedupe     *Deduper // collapses repeated dumps into their first row
+	alerts     *AlertEngine
+	history    atomic.Bool  // accepted dumps are saved to the history store
+	maxDumps   atomic.Int64 // history retention: dumps kept
+	maxAge     atomic.Int64 // history retention: nanoseconds a dump is kept
+	saved      atomic.Int64 // dumps saved, to prune every historyPruneInterval
+	autoDiff   atomic.Bool
+	gitContext atomic.Bool
+}
+
+// newProfilePipeline creates the unconfigured pipeline of profile name.
+func newProfilePipeline(name string) *profilePipeline {
+	return &profilePipeline{
+		name:     name,
+		labels:   NewLabelIndex(defaultLabelHistory),
+		services: NewServiceRegistry(),
+		dedupe:   NewDeduper(),
+		alerts:   NewAlertEngine(),
+	}
+}
+
+// profileRuntime is the listener, log watcher and pipeline of one running
+// profile.
+type profileRuntime struct {
+	profile   Profile
+	pipeline  *profilePipeline
+	server    *http.Server
+	cancel    context.CancelFunc
+	watcher   *LogWatcher
+	startedAt time.Time
+	messages  atomic.Int64
+}
+
+// ProfileSupervisor runs any number of profiles side by side, each with its
+// own HTTP listener, log watcher and pipeline. Listeners of different
+// profiles must not share a port or socket.
+type ProfileSupervisor struct {
+	// ops serializes starting and stopping, which can wait seconds for a
+	// listener to drain; mu only guards running, so the lookups request
+	// handlers make never wait for a shutdown.
+	ops     sync.Mutex
+	mu      sync.Mutex
+	running map[string]*profileRuntime
+
+	// serve starts the listener of a profile and watch creates its log
+	// watcher, both feeding the profile's pipeline; configure applies the
+	// profile's settings to the pipeline and is optional.
+	serve     func(ctx context.Context, profile Profile, pipeline *profilePipeline) *http.Server
+	watch     func(profile Profile, pipeline *profilePipeline) (*LogWatcher, error)
+	configure func(profile Profile, pipeline *profilePipeline)
+}
+
+// NewProfileSupervisor creates a supervisor using serve and watch to start
+// the listener and log watcher of each profile, and configure to apply each
+// profile to its pipeline.
+func NewProfileSupervisor(
+	serve func(ctx context.Context, profile Profile, pipeline *profilePipeline) *http.Server,
+	watch func(profile Profile, pipeline *profilePipeline) (*LogWatcher, error),
+	configure func(profile Profile, pipeline *profilePipeline),
+) *ProfileSupervisor {
+	return &ProfileSupervisor{
+		running:   make(map[string]*profileRuntime),
+		serve:     serve,
+		watch:     watch,
+		configure: configure,
+	}
+}
+
+// listenerConflict returns why profile cannot listen next to the running
+// profiles, or "" when its port and socket are free.
+func (s *ProfileSupervisor) listenerConflict(profile Profile) string {
+	tcp := !(profile.Socket != "" && profile.DisableTCP)
+	for name, rt := range s.running {
+		if name == profile.Name || rt.server == nil {
+			continue
+		}
+		other := rt.profile
+		otherTCP := !(other.Socket != "" && other.DisableTCP)
+		if tcp && otherTCP && other.Port == profile.Port {
+			return fmt.Sprintf("port %d is already used by profile '%s'", profile.Port, name)
+		}
+		if profile.Socket != "" && other.Socket == profile.Socket {
+			return fmt.Sprintf("socket '%s' is already used by profile '%s'", profile.Socket, name)
+		}
+	}
+	return ""
+}
+
+// runtimeFor returns the runtime of name, creating it if needed, and
+// applies profile to its pipeline. Callers hold s.mu.
+func (s *ProfileSupervisor) runtimeFor(profile Profile) *profileRuntime {
+	rt, ok := s.running[profile.Name]
+	if !ok {
+		rt = &profileRuntime{pipeline: newProfilePipeline(profile.Name), startedAt: time.Now()}
+		s.running[profile.Name] = rt
+	}
+	rt.profile = profile
+	if s.configure != nil {
+		s.configure(profile, rt.pipeline)
+	}
+	return rt
+}
+
+// StartServer starts the listener of profile, replacing the one it already
+// runs, unless another running profile holds the same port or socket.
+func (s *ProfileSupervisor) StartServer(ctx context.Context, profile Profile) error {
+	s.ops.Lock()
+	defer s.ops.Unlock()
+
+	s.mu.Lock()
+	if conflict := s.listenerConflict(profile); conflict != "" {
+		s.mu.Unlock()
+		return fmt.Errorf("cannot start profile '%s': %s", profile.Name, conflict)
+	}
+	rt := s.runtimeFor(profile)
+	server, cancel := rt.takeServer()
+	s.mu.Unlock()
+
+	// The old listener must release the port before the new one binds it
+	shutdownServer(server, cancel)
+
+	serverCtx, cancel := context.WithCancel(ctx)
+	server = s.serve(serverCtx, profile, rt.pipeline)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt.server, rt.cancel = server, cancel
+	return nil
+}
+
+// StartWatcher starts the log watcher of profile, replacing the one it
+// already runs. Profiles without log folders get no watcher.
+func (s *ProfileSupervisor) StartWatcher(profile Profile) error {
+	s.ops.Lock()
+	defer s.ops.Unlock()
+
+	s.mu.Lock()
+	rt := s.runtimeFor(profile)
+	old := rt.takeWatcher()
+	s.mu.Unlock()
+
+	stopWatcher(old)
+	if len(profile.LogFolders) == 0 {
+		return nil
+	}
+
+	watcher, err := s.watch(profile, rt.pipeline)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	rt.watcher = watcher
+	s.mu.Unlock()
+	return watcher.Start(profile.LogFolders)
+}
+
+// StopWatcher stops the log watcher of name, keeping its listener.
+func (s *ProfileSupervisor) StopWatcher(name string) {
+	s.ops.Lock()
+	defer s.ops.Unlock()
+
+	var watcher *LogWatcher
+	s.mu.Lock()
+	if rt, ok := s.running[name]; ok {
+		watcher = rt.takeWatcher()
+	}
+	s.mu.Unlock()
+	stopWatcher(watcher)
+}
+
+// Stop stops the listener and log watcher of name.
+func (s *ProfileSupervisor) Stop(name string) {
+	s.ops.Lock()
+	defer s.ops.Unlock()
+
+	s.mu.Lock()
+	rt, ok := s.running[name]
+	delete(s.running, name)
+	s.mu.Unlock()
+	if ok {
+		rt.stop()
+	}
+}
+
+// StopAll stops every running profile.
+func (s *ProfileSupervisor) StopAll() {
+	s.ops.Lock()
+	defer s.ops.Unlock()
+
+	s.mu.Lock()
+	running := s.running
+	s.running = make(map[string]*profileRuntime)
+	s.mu.Unlock()
+	for _, rt := range running {
+		rt.stop()
+	}
+}
+
+// Configure applies profile to the pipeline of the running profile of the
+// same name, after its settings changed. It does nothing if the profile is
+// not running.
+func (s *ProfileSupervisor) Configure(profile Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rt, ok := s.running[profile.Name]; ok && s.configure != nil {
+		s.configure(profile, rt.pipeline)
+	}
+}
+
+// Pipeline returns the pipeline of name, or nil if it is not running.
+func (s *ProfileSupervisor) Pipeline(name string) *profilePipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rt, ok := s.running[name]; ok {
+		return rt.pipeline
+	}
+	return nil
+}
+
+// KeepsHistory reports whether any running profile saves its dumps.
+func (s *ProfileSupervisor) KeepsHistory() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rt := range s.running {
+		if rt.pipeline.history.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRunning reports whether name has a listener or log watcher.
+func (s *ProfileSupervisor) IsRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.running[name]
+	return ok
+}
+
+// Server returns the listener of name and the profile it was started with.
+func (s *ProfileSupervisor) Server(name string) (*http.Server, Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rt, ok := s.running[name]; ok {
+		return rt.server, rt.profile
+	}
+	return nil, Profile{}
+}
+
+// Watcher returns the log watcher of name, or nil.
+func (s *ProfileSupervisor) Watcher(name string) *LogWatcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rt, ok := s.running[name]; ok {
+		return rt.watcher
+	}
+	return nil
+}
+
+// CountMessage records a message accepted by the listener of name.
+func (s *ProfileSupervisor) CountMessage(name string) {
+	s.mu.Lock()
+	rt, ok := s.running[name]
+	s.mu.Unlock()
+	if ok {
+		rt.messages.Add(1)
+	}
+}
+
+// Status lists the running profiles by name, marking active.
+func (s *ProfileSupervisor) Status(active string) []ProfileStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]ProfileStatus, 0, len(s.running))
+	for name, rt := range s.running {
+		status := ProfileStatus{
+			Name:      name,
+			Active:    name == active,
+			Socket:    rt.profile.Socket,
+			TLS:       rt.server != nil && rt.server.TLSConfig != nil,
+			Watching:  rt.watcher != nil,
+			StartedAt: rt.startedAt,
+			Messages:  rt.messages.Load(),
+		}
+		if rt.server != nil {
+			status.Address = rt.server.Addr
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// takeServer detaches the listener of rt so it can be shut down without
+// holding s.mu. Callers hold s.mu.
+func (rt *profileRuntime) takeServer() (*http.Server, context.CancelFunc) {
+	server, cancel := rt.server, rt.cancel
+	rt.server, rt.cancel = nil, nil
+	return server, cancel
+}
+
+// takeWatcher detaches the log watcher of rt so it can be stopped without
+// holding s.mu. Callers hold s.mu.
+func (rt *profileRuntime) takeWatcher() *LogWatcher {
+	watcher := rt.watcher
+	rt.watcher = nil
+	return watcher
+}
+
+// stop stops the listener and log watcher of a runtime already removed
+// from the supervisor.
+func (rt *profileRuntime) stop() {
+	stopWatcher(rt.takeWatcher())
+	shutdownServer(rt.takeServer())
+}
+
+// shutdownServer shuts a listener down, waiting up to 3 seconds for
+// in-flight requests to complete.
+func shutdownServer(server *http.Server, cancel context.CancelFunc) {
+	if cancel == nil {
+		return
+	}
+	cancel()
+	if server != nil {
+		shutCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutCtx)
+	}
+}
+
+func stopWatcher(watcher *LogWatcher) {
+	if watcher != nil {
+		watcher.Stop()
+	}
+}
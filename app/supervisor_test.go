@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSupervisor returns a supervisor whose listeners are never bound
+func newTestSupervisor(started *[]string) *ProfileSupervisor {
+	serve := func(ctx context.Context, profile Profile, pipeline *profilePipeline) *http.Server {
+		*started = append(*started, profile.Name)
+		return &http.Server{Addr: fmt.Sprintf("%s:%d", profile.Server, profile.Port)}
+	}
+	watch := func(profile Profile, pipeline *profilePipeline) (*LogWatcher, error) {
+		return nil, fmt.Errorf("no watcher in tests")
+	}
+	configure := func(profile Profile, pipeline *profilePipeline) {
+		pipeline.services.SetMuted(profile.MutedServices)
+		pipeline.history.Store(profile.History)
+	}
+	return NewProfileSupervisor(serve, watch, configure)
+}
+
+// TestProfileSupervisor tests running several profiles side by side
+func TestProfileSupervisor(t *testing.T) {
+	var started []string
+	s := newTestSupervisor(&started)
+	defer s.StopAll()
+
+	api := Profile{Name: "api", Server: "localhost", Port: 9191}
+	worker := Profile{Name: "worker", Server: "localhost", Port: 9192, Socket: "/tmp/worker.sock"}
+
+	if err := s.StartServer(context.Background(), api); err != nil {
+		t.Fatalf("StartServer(api) failed: %v", err)
+	}
+	if err := s.StartServer(context.Background(), worker); err != nil {
+		t.Fatalf("StartServer(worker) failed: %v", err)
+	}
+
+	// Restarting a profile replaces its own listener
+	api.Port = 9193
+	if err := s.StartServer(context.Background(), api); err != nil {
+		t.Fatalf("Restarting api failed: %v", err)
+	}
+	if len(started) != 3 {
+		t.Errorf("Expected 3 listener starts, got %v", started)
+	}
+
+	s.CountMessage("worker")
+	s.CountMessage("worker")
+	s.CountMessage("unknown")
+
+	status := s.Status("api")
+	if len(status) != 2 || status[0].Name != "api" || status[1].Name != "worker" {
+		t.Fatalf("Unexpected status: %+v", status)
+	}
+	if !status[0].Active || status[1].Active {
+		t.Error("Only api should be active")
+	}
+	if status[0].Address != "localhost:9193" || status[1].Messages != 2 || status[1].Socket != "/tmp/worker.sock" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	s.Stop("worker")
+	if s.IsRunning("worker") || !s.IsRunning("api") {
+		t.Error("Stop should only stop worker")
+	}
+	if server, _ := s.Server("worker"); server != nil {
+		t.Error("Stopped profile should have no server")
+	}
+}
+
+// TestProfileSupervisor_Conflicts tests that listeners cannot share a port or socket
+func TestProfileSupervisor_Conflicts(t *testing.T) {
+	var started []string
+	s := newTestSupervisor(&started)
+	defer s.StopAll()
+
+	s.StartServer(context.Background(), Profile{Name: "api", Server: "localhost", Port: 9191, Socket: "/tmp/vd.sock"})
+
+	testCases := []struct {
+		name     string
+		profile  Profile
+		conflict string
+	}{
+		{"same port", Profile{Name: "b", Server: "0.0.0.0", Port: 9191}, "port 9191"},
+		{"same socket", Profile{Name: "c", Port: 9200, Socket: "/tmp/vd.sock", DisableTCP: true}, "socket '/tmp/vd.sock'"},
+		{"socket only on same port", Profile{Name: "d", Port: 9191, Socket: "/tmp/d.sock", DisableTCP: true}, ""},
+		{"other port", Profile{Name: "e", Port: 9300}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.StartServer(context.Background(), tc.profile)
+			if tc.conflict == "" {
+				if err != nil {
+					t.Errorf("Expected no conflict, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.conflict) {
+				t.Errorf("Expected a conflict on %s, got %v", tc.conflict, err)
+			}
+			if s.IsRunning(tc.profile.Name) {
+				t.Error("A conflicting profile should not be registered")
+			}
+		})
+	}
+}
+
+// TestProfileSupervisor_Watcher tests that profiles without log folders get no watcher
+func TestProfileSupervisor_Watcher(t *testing.T) {
+	var started []string
+	s := newTestSupervisor(&started)
+
+	if err := s.StartWatcher(Profile{Name: "api"}); err != nil {
+		t.Errorf("A profile without folders should not need a watcher: %v", err)
+	}
+	if s.Watcher("api") != nil {
+		t.Error("Expected no watcher")
+	}
+
+	err := s.StartWatcher(Profile{Name: "api", LogFolders: []LogFolder{{Path: "/var/log", Enabled: true}}})
+	if err == nil {
+		t.Error("Watcher errors should be returned")
+	}
+}
+
+// TestProfileSupervisor_Pipelines tests that each profile applies its own settings
+func TestProfileSupervisor_Pipelines(t *testing.T) {
+	var started []string
+	s := newTestSupervisor(&started)
+	defer s.StopAll()
+
+	api := Profile{Name: "api", Server: "localhost", Port: 9191, MutedServices: []string{"billing"}, History: true}
+	worker := Profile{Name: "worker", Server: "localhost", Port: 9192}
+	s.StartServer(context.Background(), api)
+	s.StartServer(context.Background(), worker)
+
+	apiPipeline, workerPipeline := s.Pipeline("api"), s.Pipeline("worker")
+	if apiPipeline == nil || workerPipeline == nil || apiPipeline == workerPipeline {
+		t.Fatal("Each running profile should have its own pipeline")
+	}
+	if deliver, _ := apiPipeline.services.Observe("billing", time.Now()); deliver {
+		t.Error("billing should be muted on api")
+	}
+	if deliver, _ := workerPipeline.services.Observe("billing", time.Now()); !deliver {
+		t.Error("billing should not be muted on worker")
+	}
+	if !s.KeepsHistory() {
+		t.Error("api keeps history")
+	}
+
+	// Settings changes only reach the pipeline of their own profile
+	worker.MutedServices = []string{"billing"}
+	s.Configure(worker)
+	api.History = false
+	s.Configure(api)
+	if deliver, _ := workerPipeline.services.Observe("billing", time.Now()); deliver {
+		t.Error("billing should now be muted on worker")
+	}
+	if s.KeepsHistory() {
+		t.Error("No profile keeps history anymore")
+	}
+
+	s.Configure(Profile{Name: "stopped", History: true})
+	if s.Pipeline("stopped") != nil || s.KeepsHistory() {
+		t.Error("Configuring a stopped profile should not start it")
+	}
+}
+
+// TestProfileSupervisor_RestartInFlight tests that lookups do not wait for a
+// listener draining an in-flight request
+func TestProfileSupervisor_RestartInFlight(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	addr := make(chan string, 2)
+	serve := func(ctx context.Context, profile Profile, pipeline *profilePipeline) *http.Server {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		server := &http.Server{Addr: ln.Addr().String(), Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+		})}
+		go server.Serve(ln)
+		addr <- server.Addr
+		return server
+	}
+	s := NewProfileSupervisor(serve, nil, nil)
+	defer s.StopAll()
+
+	profile := Profile{Name: "api", Server: "127.0.0.1"}
+	if err := s.StartServer(context.Background(), profile); err != nil {
+		t.Fatalf("StartServer failed: %v", err)
+	}
+	go http.Get("http://" + <-addr + "/data")
+	<-entered
+
+	restarted := make(chan struct{})
+	go func() {
+		s.StartServer(context.Background(), profile)
+		close(restarted)
+	}()
+
+	// The restart detaches the old listener before it waits for it to drain
+	done := make(chan struct{})
+	go func() {
+		for {
+			if server, _ := s.Server("api"); server == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		s.CountMessage("api")
+		s.Status("api")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Lookups blocked while the listener was draining")
+	}
+
+	close(release)
+	<-restarted
+	if server, _ := s.Server("api"); server == nil {
+		t.Error("Expected the restarted listener")
+	}
+}
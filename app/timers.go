@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxTimerSamples bounds the durations kept per timer for percentiles.
+const maxTimerSamples = 1000
+
+// Running timers that never get a stop are abandoned after timerRunTTL, and
+// at most maxRunningTimers runs are tracked at once.
+const (
+	timerRunTTL      = time.Hour
+	maxRunningTimers = 1000
+)
+
+// Timer actions accepted by /timer.
+const (
+	TimerStart = "start"
+	TimerStop  = "stop"
+	TimerLap   = "lap"
+)
+
+// TimerEvent is a single start/stop/lap posted by a client. Run distinguishes
+// concurrent runs of the same timer (e.g. one per worker process).
+type TimerEvent struct {
+	Name   string    `json:"name"`
+	Action string    `json:"action"`
+	Run    string    `json:"run,omitempty"`
+	At     time.Time `json:"at"`
+	Memory int64     `json:"memory,omitempty"` // bytes in use when the event was sent
+}
+
+// BenchmarkRecord aggregates the completed runs of one timer. Durations are
+// in milliseconds and memory in bytes.
+type BenchmarkRecord struct {
+	Name          string    `json:"name"`
+	Count         int       `json:"count"`
+	Running       int       `json:"running"`
+	TotalMs       float64   `json:"total_ms"`
+	MinMs         float64   `json:"min_ms"`
+	MaxMs         float64   `json:"max_ms"`
+	AvgMs         float64   `json:"avg_ms"`
+	P95Ms         float64   `json:"p95_ms"`
+	LastMs        float64   `json:"last_ms"`
+	LastLapMs     float64   `json:"last_lap_ms,omitempty"`
+	Laps          int       `json:"laps,omitempty"`
+	Restarts      int       `json:"restarts,omitempty"`  // starts of a run that was already running
+	Abandoned     int       `json:"abandoned,omitempty"` // runs dropped without a stop
+	LastMemDelta  int64     `json:"last_memory_delta"`
+	MaxMemDelta   int64     `json:"max_memory_delta"`
+	AvgMemDelta   float64   `json:"avg_memory_delta"`
+	LastUpdatedAt time.Time `json:"last_updated_at"`
+}
+
+type timerKey struct {
+	name string
+	run  string
+}
+
+type runningTimer struct {
+	start       time.Time
+	lastLap     time.Time
+	startMemory int64
+	startedAt   time.Time // server time of the start, for expiry
+}
+
+type timerStats struct {
+	record   BenchmarkRecord
+	samples  []float64 // most recent durations, bounded by maxTimerSamples
+	memTotal int64
+}
+
+// TimerAggregator correlates timer events across requests and keeps
+// per-name duration and memory statistics.
+type TimerAggregator struct {
+	mu      sync.Mutex
+	running map[timerKey]*runningTimer
+	stats   map[string]*timerStats
+	now     func() time.Time
+}
+
+// NewTimerAggregator creates an empty aggregator.
+func NewTimerAggregator() *TimerAggregator {
+	return &TimerAggregator{
+		running: make(map[timerKey]*runningTimer),
+		stats:   make(map[string]*timerStats),
+		now:     time.Now,
+	}
+}
+
+// Record applies ev and returns the updated record for its timer.
+func (a *TimerAggregator) Record(ev TimerEvent) (*BenchmarkRecord, error) {
+	if ev.Name == "" {
+		return nil, &PayloadError{Field: "name", Message: "is required"}
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	a.expireLocked(now)
+	key := timerKey{name: ev.Name, run: ev.Run}
+	rt, running := a.running[key]
+	switch ev.Action {
+	case TimerStart:
+	case TimerLap, TimerStop:
+		if !running {
+			return nil, &PayloadError{Field: "name", Message: fmt.Sprintf("timer '%s' is not running", ev.Name)}
+		}
+	default:
+		return nil, &PayloadError{Field: "action", Message: "must be 'start', 'stop' or 'lap'"}
+	}
+
+	stats, ok := a.stats[ev.Name]
+	if !ok {
+		stats = &timerStats{record: BenchmarkRecord{Name: ev.Name}}
+		a.stats[ev.Name] = stats
+	}
+
+	switch ev.Action {
+	case TimerStart:
+		if running {
+			stats.record.Restarts++
+		} else if len(a.running) >= maxRunningTimers {
+			a.abandonOldestLocked()
+		}
+		a.running[key] = &runningTimer{start: ev.At, lastLap: ev.At, startMemory: ev.Memory, startedAt: now}
+	case TimerLap:
+		stats.record.LastLapMs = durationMs(ev.At.Sub(rt.lastLap))
+		stats.record.Laps++
+		rt.lastLap = ev.At
+	case TimerStop:
+		delete(a.running, key)
+		stats.addSample(durationMs(ev.At.Sub(rt.start)), ev.Memory-rt.startMemory)
+	}
+
+	stats.record.LastUpdatedAt = now
+	record := a.recordLocked(ev.Name)
+	return &record, nil
+}
+
+// expireLocked abandons the runs started more than timerRunTTL before now.
+// Callers hold a.mu.
+func (a *TimerAggregator) expireLocked(now time.Time) {
+	for key, rt := range a.running {
+		if now.Sub(rt.startedAt) > timerRunTTL {
+			a.abandonLocked(key)
+		}
+	}
+}
+
+// abandonOldestLocked abandons the run started first, to make room for a
+// new one. Callers hold a.mu.
+func (a *TimerAggregator) abandonOldestLocked() {
+	var oldest timerKey
+	var oldestAt time.Time
+	for key, rt := range a.running {
+		if oldestAt.IsZero() || rt.startedAt.Before(oldestAt) {
+			oldest, oldestAt = key, rt.startedAt
+		}
+	}
+	a.abandonLocked(oldest)
+}
+
+// abandonLocked drops a run without recording a duration and counts it on
+// its timer. Callers hold a.mu.
+func (a *TimerAggregator) abandonLocked(key timerKey) {
+	delete(a.running, key)
+	if stats, ok := a.stats[key.name]; ok {
+		stats.record.Abandoned++
+	}
+}
+
+// Records returns every timer, most recently updated first.
+func (a *TimerAggregator) Records() []BenchmarkRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expireLocked(a.now())
+	out := make([]BenchmarkRecord, 0, len(a.stats))
+	for name := range a.stats {
+		out = append(out, a.recordLocked(name))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastUpdatedAt.After(out[j].LastUpdatedAt) })
+	return out
+}
+
+// Reset discards all timers, including running ones.
+func (a *TimerAggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running = make(map[timerKey]*runningTimer)
+	a.stats = make(map[string]*timerStats)
+}
+
+func (a *TimerAggregator) recordLocked(name string) BenchmarkRecord {
+	record := a.stats[name].record
+	for key := range a.running {
+		if key.name == name {
+			record.Running++
+		}
+	}
+	return record
+}
+
+func (s *timerStats) addSample(ms float64, memDelta int64) {
+	r := &s.record
+	r.Count++
+	r.TotalMs += ms
+	r.LastMs = ms
+	if r.Count == 1 || ms < r.MinMs {
+		r.MinMs = ms
+	}
+	if ms > r.MaxMs {
+		r.MaxMs = ms
+	}
+	r.AvgMs = r.TotalMs / float64(r.Count)
+
+	r.LastMemDelta = memDelta
+	if r.Count == 1 || memDelta > r.MaxMemDelta {
+		r.MaxMemDelta = memDelta
+	}
+	s.memTotal += memDelta
+	r.AvgMemDelta = float64(s.memTotal) / float64(r.Count)
+
+	if len(s.samples) >= maxTimerSamples {
+		copy(s.samples, s.samples[1:])
+		s.samples = s.samples[:len(s.samples)-1]
+	}
+	s.samples = append(s.samples, ms)
+	r.P95Ms = percentile(s.samples, 95)
+}
+
+// percentile returns the nearest-rank percentile of samples.
+func percentile(samples []float64, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ParseTimerEvent reads a /timer body: {"name", "action", "run", "memory",
+// "at"}, where "at" is an optional client timestamp in seconds (as returned
+// by PHP's microtime(true)) so network latency does not skew durations.
+func ParseTimerEvent(body []byte) (TimerEvent, error) {
+	obj, err := decodePayloadObject(body)
+	if err != nil {
+		return TimerEvent{}, err
+	}
+
+	var ev TimerEvent
+	if ev.Name, err = optionalString(obj, "name", "name"); err != nil {
+		return ev, err
+	}
+	if ev.Action, err = optionalString(obj, "action", "action"); err != nil {
+		return ev, err
+	}
+	if ev.Run, err = optionalString(obj, "run", "run"); err != nil {
+		return ev, err
+	}
+	memory, err := optionalInt(obj, "memory", "memory")
+	if err != nil {
+		return ev, err
+	}
+	ev.Memory = int64(memory)
+
+	if ev.At, err = optionalUnixSeconds(obj, "at", "at"); err != nil {
+		return ev, err
+	}
+	return ev, nil
+}
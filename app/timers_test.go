@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestTimerAggregator_StartStop tests duration and memory statistics across runs
+func TestTimerAggregator_StartStop(t *testing.T) {
+	agg := NewTimerAggregator()
+	base := time.Now()
+
+	durations := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 200 * time.Millisecond}
+	for i, d := range durations {
+		start := base.Add(time.Duration(i) * time.Second)
+		if _, err := agg.Record(TimerEvent{Name: "import", Action: TimerStart, At: start, Memory: 1000}); err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+		if _, err := agg.Record(TimerEvent{Name: "import", Action: TimerStop, At: start.Add(d), Memory: 1000 + int64(i+1)*500}); err != nil {
+			t.Fatalf("stop failed: %v", err)
+		}
+	}
+
+	records := agg.Records()
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.Count != 3 || r.Running != 0 {
+		t.Errorf("Expected 3 completed runs, got count=%d running=%d", r.Count, r.Running)
+	}
+	if r.MinMs != 100 || r.MaxMs != 300 || r.AvgMs != 200 || r.TotalMs != 600 {
+		t.Errorf("Unexpected durations: %+v", r)
+	}
+	if r.P95Ms != 300 {
+		t.Errorf("Expected p95 300, got %v", r.P95Ms)
+	}
+	if r.LastMs != 200 {
+		t.Errorf("Expected last 200, got %v", r.LastMs)
+	}
+	if r.LastMemDelta != 1500 || r.MaxMemDelta != 1500 || r.AvgMemDelta != 1000 {
+		t.Errorf("Unexpected memory deltas: %+v", r)
+	}
+}
+
+// TestTimerAggregator_RunsAndLaps tests concurrent runs of one timer and lap splits
+func TestTimerAggregator_RunsAndLaps(t *testing.T) {
+	agg := NewTimerAggregator()
+	base := time.Now()
+
+	agg.Record(TimerEvent{Name: "job", Action: TimerStart, Run: "a", At: base})
+	agg.Record(TimerEvent{Name: "job", Action: TimerStart, Run: "b", At: base})
+
+	record, err := agg.Record(TimerEvent{Name: "job", Action: TimerLap, Run: "a", At: base.Add(50 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("lap failed: %v", err)
+	}
+	if record.Laps != 1 || record.LastLapMs != 50 || record.Running != 2 {
+		t.Errorf("Unexpected record after lap: %+v", record)
+	}
+
+	record, _ = agg.Record(TimerEvent{Name: "job", Action: TimerStop, Run: "b", At: base.Add(80 * time.Millisecond)})
+	if record.Count != 1 || record.LastMs != 80 || record.Running != 1 {
+		t.Errorf("Unexpected record after stop: %+v", record)
+	}
+
+	agg.Reset()
+	if len(agg.Records()) != 0 {
+		t.Error("Reset() should discard all timers")
+	}
+}
+
+// TestTimerAggregator_Invalid tests rejected timer events
+func TestTimerAggregator_Invalid(t *testing.T) {
+	agg := NewTimerAggregator()
+
+	testCases := []TimerEvent{
+		{Action: TimerStart},
+		{Name: "x", Action: "pause"},
+		{Name: "x", Action: TimerStop},
+		{Name: "x", Action: TimerLap},
+	}
+	for _, ev := range testCases {
+		if _, err := agg.Record(ev); err == nil {
+			t.Errorf("Record(%+v) should fail", ev)
+		}
+	}
+
+	if len(agg.Records()) != 0 {
+		t.Error("Rejected events should not create records")
+	}
+}
+
+// TestTimerAggregator_RestartAndExpiry tests counting restarts and abandoning runs without a stop
+func TestTimerAggregator_RestartAndExpiry(t *testing.T) {
+	agg := NewTimerAggregator()
+	now := time.Now()
+	agg.now = func() time.Time { return now }
+
+	agg.Record(TimerEvent{Name: "job", Action: TimerStart, At: now})
+	record, err := agg.Record(TimerEvent{Name: "job", Action: TimerStart, At: now.Add(time.Second)})
+	if err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	if record.Restarts != 1 || record.Running != 1 {
+		t.Errorf("Expected one restarted run, got %+v", record)
+	}
+
+	now = now.Add(timerRunTTL + time.Minute)
+	records := agg.Records()
+	if len(records) != 1 || records[0].Running != 0 || records[0].Abandoned != 1 {
+		t.Errorf("Expected the stale run to be abandoned, got %+v", records)
+	}
+	if _, err := agg.Record(TimerEvent{Name: "job", Action: TimerStop, At: now}); err == nil {
+		t.Error("Stopping an abandoned run should fail")
+	}
+
+	for i := 0; i < maxRunningTimers+1; i++ {
+		now = now.Add(time.Millisecond)
+		agg.Record(TimerEvent{Name: "worker", Action: TimerStart, Run: fmt.Sprintf("run-%d", i), At: now})
+	}
+	record, _ = agg.Record(TimerEvent{Name: "worker", Action: TimerLap, Run: "run-1", At: now})
+	if record.Running != maxRunningTimers || record.Abandoned != 1 {
+		t.Errorf("Expected %d running and 1 abandoned, got %+v", maxRunningTimers, record)
+	}
+	if _, err := agg.Record(TimerEvent{Name: "worker", Action: TimerStop, Run: "run-0", At: now}); err == nil {
+		t.Error("The oldest run should be abandoned when the limit is reached")
+	}
+}
+
+// TestParseTimerEvent tests reading a /timer body with a client timestamp
+func TestParseTimerEvent(t *testing.T) {
+	ev, err := ParseTimerEvent([]byte(`{"name": "query", "action": "start", "run": "w1", "memory": 2048, "at": 1700000000.25}`))
+	if err != nil {
+		t.Fatalf("ParseTimerEvent() failed: %v", err)
+	}
+	if ev.Name != "query" || ev.Action != TimerStart || ev.Run != "w1" || ev.Memory != 2048 {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+	if !ev.At.Equal(time.Unix(1700000000, 250000000)) {
+		t.Errorf("Unexpected timestamp: %v", ev.At)
+	}
+
+	if _, err := ParseTimerEvent([]byte(`{"name": "q", "at": "soon"}`)); err == nil {
+		t.Error("ParseTimerEvent() should reject a non-numeric timestamp")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	caCertFile     = "ca.pem"
+	caKeyFile      = "ca-key.pem"
+	serverCertFile = "server.pem"
+	serverKeyFile  = "server-key.pem"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	leafValidity = 825 * 24 * time.Hour // the most clients accept for a leaf
+	leafRenewal  = 30 * 24 * time.Hour  // renew a leaf this close to expiry
+)
+
+// getTLSDir returns the directory holding the generated certificates,
+// next to config.yml.
+func getTLSDir() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "tls"), nil
+}
+
+// resolveServerCert returns the certificate and key files to serve for
+// profile: the user-supplied pair when both are set, otherwise a leaf
+// signed by the local VersaDumps CA.
+func resolveServerCert(profile Profile) (certFile, keyFile string, err error) {
+	if profile.TLSCert != "" || profile.TLSKey != "" {
+		if profile.TLSCert == "" || profile.TLSKey == "" {
+			return "", "", fmt.Errorf("tls_cert and tls_key must be set together")
+		}
+		if _, err := tls.LoadX509KeyPair(profile.TLSCert, profile.TLSKey); err != nil {
+			return "", "", fmt.Errorf("invalid TLS certificate: %v", err)
+		}
+		return profile.TLSCert, profile.TLSKey, nil
+	}
+
+	dir, err := getTLSDir()
+	if err != nil {
+		return "", "", err
+	}
+	return EnsureLocalCerts(dir, certHosts(profile.Server))
+}
+
+// certHosts returns the names a leaf must cover for a server bound to host.
+// Containers usually reach the host as host.docker.internal or by IP.
+func certHosts(host string) []string {
+	hosts := []string{"localhost", "host.docker.internal", "127.0.0.1", "::1"}
+	ip := net.ParseIP(host)
+	switch {
+	case host == "" || (ip != nil && ip.IsUnspecified()):
+		if addrs, err := net.InterfaceAddrs(); err == nil {
+			for _, addr := range addrs {
+				if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+					hosts = append(hosts, ipNet.IP.String())
+				}
+			}
+		}
+	default:
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// EnsureLocalCerts loads or creates the CA in dir and a leaf covering hosts.
+// The leaf is reissued when it is missing, close to expiry or lacks a host.
+func EnsureLocalCerts(dir string, hosts []string) (certFile, keyFile string, err error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	ca, caKey, err := loadOrCreateCA(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	certFile = filepath.Join(dir, serverCertFile)
+	keyFile = filepath.Join(dir, serverKeyFile)
+	if leafUsable(certFile, keyFile, ca, hosts) {
+		return certFile, keyFile, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber: newSerialNumber(),
+		Subject:      pkix.Name{Organization: []string{"VersaDumps"}, CommonName: "VersaDumps local server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create server certificate: %v", err)
+	}
+	if err := writeCertPair(certFile, keyFile, der, key); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// loadOrCreateCA returns the CA stored in dir, creating it on first use.
+func loadOrCreateCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certFile := filepath.Join(dir, caCertFile)
+	keyFile := filepath.Join(dir, caKeyFile)
+
+	if pair, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if err == nil && ok && time.Now().Before(cert.NotAfter) {
+			return cert, key, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          newSerialNumber(),
+		Subject:               pkix.Name{Organization: []string{"VersaDumps"}, CommonName: "VersaDumps Local CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %v", err)
+	}
+	if err := writeCertPair(certFile, keyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+	// A new CA invalidates the old leaf.
+	os.Remove(filepath.Join(dir, serverCertFile))
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// leafUsable reports whether the stored leaf is signed by ca, not close to
+// expiry and valid for every host.
+func leafUsable(certFile, keyFile string, ca *x509.Certificate, hosts []string) bool {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return false
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || time.Until(leaf.NotAfter) < leafRenewal {
+		return false
+	}
+	if leaf.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	for _, h := range hosts {
+		if leaf.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCertPair(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", keyFile, err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", certFile, err)
+	}
+	return nil
+}
+
+func newSerialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
+
+// pinnedTLSConfig trusts exactly the certificate leaf (DER). The app uses
+// it to reach its own server whatever signed that certificate.
+func pinnedTLSConfig(leaf []byte) *tls.Config {
+	return &tls.Config{
+		// Verification is replaced by the exact match below.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], leaf) {
+				return fmt.Errorf("server certificate does not match")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEnsureLocalCerts tests that the generated leaf chains to the CA and covers the hosts
+func TestEnsureLocalCerts(t *testing.T) {
+	dir := t.TempDir()
+	hosts := []string{"localhost", "host.docker.internal", "127.0.0.1", "192.168.1.50"}
+
+	certFile, keyFile, err := EnsureLocalCerts(dir, hosts)
+	if err != nil {
+		t.Fatalf("EnsureLocalCerts() failed: %v", err)
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Generated pair does not load: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(pair.Certificate[0])
+
+	caPEM, err := os.ReadFile(filepath.Join(dir, caCertFile))
+	if err != nil {
+		t.Fatalf("CA was not written: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+	for _, h := range hosts {
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: h, Roots: roots}); err != nil {
+			t.Errorf("Leaf does not verify for %s: %v", h, err)
+		}
+	}
+
+	if info, err := os.Stat(keyFile); err == nil && info.Mode().Perm()&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("Key file should not be readable by others: %v", info.Mode())
+	}
+
+	// Same hosts reuse the leaf; a new host reissues it under the same CA
+	EnsureLocalCerts(dir, hosts)
+	again, _ := tls.LoadX509KeyPair(certFile, keyFile)
+	if string(again.Certificate[0]) != string(pair.Certificate[0]) {
+		t.Error("Leaf should be reused when it still covers every host")
+	}
+
+	EnsureLocalCerts(dir, append(hosts, "10.0.0.9"))
+	reissued, _ := tls.LoadX509KeyPair(certFile, keyFile)
+	if string(reissued.Certificate[0]) == string(pair.Certificate[0]) {
+		t.Error("Leaf should be reissued for a new host")
+	}
+	caAgain, _ := os.ReadFile(filepath.Join(dir, caCertFile))
+	if string(caAgain) != string(caPEM) {
+		t.Error("CA should be kept across leaf renewals")
+	}
+}
+
+// TestResolveServerCert tests user-supplied certificate validation
+func TestResolveServerCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := EnsureLocalCerts(dir, []string{"localhost"})
+	if err != nil {
+		t.Fatalf("EnsureLocalCerts() failed: %v", err)
+	}
+
+	gotCert, gotKey, err := resolveServerCert(Profile{TLS: true, TLSCert: certFile, TLSKey: keyFile})
+	if err != nil || gotCert != certFile || gotKey != keyFile {
+		t.Errorf("User pair should be used as is, got %s %s %v", gotCert, gotKey, err)
+	}
+
+	if _, _, err := resolveServerCert(Profile{TLS: true, TLSCert: certFile}); err == nil {
+		t.Error("A certificate without a key should be rejected")
+	}
+	if _, _, err := resolveServerCert(Profile{TLS: true, TLSCert: keyFile, TLSKey: certFile}); err == nil {
+		t.Error("A swapped pair should be rejected")
+	}
+}
+
+// TestPinnedTLSConfig tests reaching a server by its exact certificate
+func TestPinnedTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	leaf := server.TLS.Certificates[0].Certificate[0]
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: pinnedTLSConfig(leaf)}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Pinned request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	certFile, keyFile, err := EnsureLocalCerts(t.TempDir(), []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("EnsureLocalCerts() failed: %v", err)
+	}
+	pair, _ := tls.LoadX509KeyPair(certFile, keyFile)
+	other := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	other.TLS = &tls.Config{Certificates: []tls.Certificate{pair}}
+	other.StartTLS()
+	defer other.Close()
+	if _, err := client.Get(other.URL); err == nil {
+		t.Error("A different certificate should be refused")
+	}
+}
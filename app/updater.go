@@ -46,6 +46,7 @@ type UpdateManager struct {
 	owner      string
 	repo       string
 	httpClient *http.Client
+	metrics    *Metrics // counts check results; optional
 }
 
 func NewUpdateManager() *UpdateManager {
@@ -78,6 +79,7 @@ func (um *UpdateManager) CheckForUpdates() (*UpdateInfo, error) {
 	resp, err := um.httpClient.Do(req)
 	if err != nil {
 		fmt.Printf("CheckForUpdates: Error making request: %v\n", err)
+		um.metrics.Inc(metricUpdateChecks, UpdateCheckError)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -92,6 +94,7 @@ func (um *UpdateManager) CheckForUpdates() (*UpdateInfo, error) {
 		// en lugar de una actualización falsa
 		if resp.StatusCode == 403 {
 			fmt.Printf("CheckForUpdates: Rate limiting detected, returning no update available\n")
+			um.metrics.Inc(metricUpdateChecks, UpdateCheckRateLimited)
 			return &UpdateInfo{
 				Available:      false,          // Changed from true to false
 				Version:        CurrentVersion, // Use current version instead of fake version
@@ -103,12 +106,14 @@ func (um *UpdateManager) CheckForUpdates() (*UpdateInfo, error) {
 			}, nil
 		}
 
+		um.metrics.Inc(metricUpdateChecks, UpdateCheckError)
 		return nil, fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
 	}
 
 	var release GitHubRelease
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
 		fmt.Printf("CheckForUpdates: Error decoding response: %v\n", err)
+		um.metrics.Inc(metricUpdateChecks, UpdateCheckError)
 		return nil, err
 	}
 
@@ -124,6 +129,7 @@ func (um *UpdateManager) CheckForUpdates() (*UpdateInfo, error) {
 
 	if comparison <= 0 {
 		fmt.Printf("CheckForUpdates: No update available\n")
+		um.metrics.Inc(metricUpdateChecks, UpdateCheckUpToDate)
 		return &UpdateInfo{
 			Available:      false,
 			CurrentVersion: CurrentVersion,
@@ -134,6 +140,7 @@ func (um *UpdateManager) CheckForUpdates() (*UpdateInfo, error) {
 	// Buscar el asset correcto para el SO actual
 	downloadURL, size := um.getDownloadURL(release.Assets)
 
+	um.metrics.Inc(metricUpdateChecks, UpdateCheckAvailable)
 	fmt.Printf("CheckForUpdates: Update available! Version: %s, Download URL: %s\n", latestVersion, downloadURL)
 
 	return &UpdateInfo{